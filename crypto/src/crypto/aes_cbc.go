@@ -1,7 +1,6 @@
 package crypto
 
 import (
-	"bufio"
 	"bytes"
 	"crypto/aes"
 	"crypto/cipher"
@@ -30,23 +29,23 @@ func pkcs7Pad(data []byte, blockSize int) []byte {
 	return append(data, padding...)
 }
 
-// pkcs7Unpad
+// pkcs7Unpad, 所有错误都返回 ErrInvalidPadding, 不区分错误的原因.
 func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
 	// data size 必须是 blockSize 的倍数.
 	if len(data) == 0 || len(data)%blockSize != 0 {
-		return nil, errors.New("invalid padded data length")
+		return nil, ErrInvalidPadding
 	}
 
 	// 0 < padSize <= blockSize
 	padLen := int(data[len(data)-1]) // data[last_byte]
 	if padLen <= 0 || padLen > blockSize {
-		return nil, fmt.Errorf("invalid padding size %d", padLen)
+		return nil, ErrInvalidPadding
 	}
 
 	// 验证 padding format. []byte{x,x,x,x,x,x,x,x,x,x,x,5,5,5,5,5}
 	padding := data[len(data)-padLen:]
 	if !bytes.Equal(padding, bytes.Repeat([]byte{byte(padLen)}, padLen)) {
-		return nil, fmt.Errorf("invalid PKCS7 padding %d", padLen)
+		return nil, ErrInvalidPadding
 	}
 	return data[:len(data)-padLen], nil
 }
@@ -79,6 +78,7 @@ func AESCBCEncrypt(plaintext, key []byte) (ciphertext []byte, err error) {
 }
 
 // 必须要知道 IV 才能正确解密.
+// ciphertext 短于 iv + 1 block 返回 ErrCiphertextTooShort, 长度不是 block size 的倍数或者 padding 错误返回 ErrInvalidPadding.
 func AESCBCDecrypt(ciphertext, key []byte) (plaintext []byte, err error) {
 	// 创建一个 AES 块密码
 	block, err := aes.NewCipher(key)
@@ -87,7 +87,13 @@ func AESCBCDecrypt(ciphertext, key []byte) (plaintext []byte, err error) {
 	}
 
 	bsize := block.BlockSize()
+	if len(ciphertext) < 2*bsize {
+		return nil, ErrCiphertextTooShort
+	}
 	iv, encryptedData := ciphertext[:bsize], ciphertext[bsize:]
+	if len(encryptedData)%bsize != 0 {
+		return nil, ErrInvalidPadding
+	}
 
 	// 解密
 	paddedText := make([]byte, len(encryptedData))
@@ -98,104 +104,152 @@ func AESCBCDecrypt(ciphertext, key []byte) (plaintext []byte, err error) {
 	return pkcs7Unpad(paddedText, block.BlockSize())
 }
 
-func AESCBCEncryptFile(srcFile, cipherFile *os.File, key []byte) (iv []byte, err error) {
+// cbcStreamBufferSize 是 CBC stream 每次读取和 Write 的大小, 必须是 block size 的倍数.
+const cbcStreamBufferSize = 32 * 1024
+
+// AESCBCEncryptStream 从 src 读取全部数据, 使用 AES-CBC 加密后写入 dst.
+// dst 的格式和 AESCBCEncrypt 相同: iv | ciphertext, iv 作为 header 最先写入.
+//
+// NOTE: CBC 没有认证, 需要认证时使用 NewEncryptWriter.
+func AESCBCEncryptStream(dst io.Writer, src io.Reader, key []byte) error {
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	iv, err = RandomBytes(block.BlockSize())
+	iv, err := RandomBytes(block.BlockSize())
 	if err != nil {
-		return nil, err
+		return err
 	}
-	encrypter := cipher.NewCBCEncrypter(block, iv)
-
-	freader := bufio.NewReader(srcFile)
-	buffer := make([]byte, block.BlockSize())
-	dst := make([]byte, block.BlockSize())
-
-	var padded bool
-	for {
-		var n int
-		n, err = freader.Read(buffer)
-		if err != nil && errors.Is(err, io.EOF) && padded {
-			// NOTE: finished EOF
-			return iv, nil
-		} else if err != nil && !errors.Is(err, io.EOF) {
-			return nil, err
-		}
-
-		if n < 0 || n > block.BlockSize() {
-			return nil, errors.New("buffer read size error")
-		} else if n >= 0 && n < block.BlockSize() {
-			// last line, NOTE: when n==0 (EOF && !padded) 说明是文件正好是 blockSize 的倍数
-			if padded {
-				return nil, errors.New("encrypt Failed: pkcs7Pad more than once, buffer read size error")
-			}
-			buffer = pkcs7Pad(buffer[:n], block.BlockSize())
-			padded = true // mark padding
-		}
-
-		// 加密数据
-		encrypter.CryptBlocks(dst, buffer)
-
-		// 分段写入文件
-		_, err = cipherFile.Write(dst)
-		if err != nil {
-			return nil, err
-		}
+	if _, err := dst.Write(iv); err != nil {
+		return err
 	}
+	return cbcEncryptBlocks(dst, src, cipher.NewCBCEncrypter(block, iv))
 }
 
-func AESCBCDecryptFile(dstFile, cipherFile *os.File, key, iv []byte) error {
+// AESCBCDecryptStream 解密 AESCBCEncryptStream 的输出, 写入 dst.
+// 没有完整的 iv 和至少一个 block 返回 ErrCiphertextTooShort, 长度或 padding 错误返回 ErrInvalidPadding.
+//
+// NOTE: 返回错误之前, 最后一个 block 之前的 plaintext 已经写入了 dst.
+func AESCBCDecryptStream(dst io.Writer, src io.Reader, key []byte) error {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return err
 	}
-	decrypter := cipher.NewCBCDecrypter(block, iv)
-
-	freader := bufio.NewReader(cipherFile)
-	buffer := make([]byte, block.BlockSize())
-	dst := make([]byte, block.BlockSize())
+	iv := make([]byte, block.BlockSize())
+	if _, err := io.ReadFull(src, iv); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrCiphertextTooShort
+		}
+		return err
+	}
+	return cbcDecryptBlocks(dst, src, cipher.NewCBCDecrypter(block, iv))
+}
 
-	var lastline bool
+// cbcEncryptBlocks encrypts src to dst, the last buffer is padded.
+// io.ReadFull 保证只有 src 结束时才会读到不完整的 buffer, 所以 padding 只会出现在最后.
+func cbcEncryptBlocks(dst io.Writer, src io.Reader, mode cipher.BlockMode) error {
+	bs := mode.BlockSize()
+	buf := make([]byte, cbcStreamBufferSize, cbcStreamBufferSize+bs) // room for padding
 	for {
-		var n int
-		n, err = freader.Read(buffer)
-		if err != nil && errors.Is(err, io.EOF) {
-			// NOTE: finished EOF
-			return nil
-		} else if err != nil && !errors.Is(err, io.EOF) {
+		n, err := io.ReadFull(src, buf)
+		last := false
+		switch {
+		case err == nil:
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			last = true
+		default:
 			return err
 		}
 
-		// 读取的数据必须是 blockSize 的倍数.
-		if n != block.BlockSize() {
-			return errors.New("buffer read size is not blockSize")
+		data := buf[:n]
+		if last {
+			// n == 0 时 pad 一个完整的 block
+			data = pkcs7Pad(data, bs)
+		}
+		mode.CryptBlocks(data, data)
+		if _, err := dst.Write(data); err != nil {
+			return err
+		}
+		if last {
+			return nil
 		}
+	}
+}
 
-		// peak for EOF
-		_, err = freader.Peek(1)
-		if err != nil && errors.Is(err, io.EOF) {
-			// next read is EOF, unpad current data.
-			lastline = true
-		} else if err != nil && !errors.Is(err, io.EOF) {
+// cbcDecryptBlocks decrypts src to dst and unpads the last block.
+// 最后一个 block 只有读到 EOF 之后才能确定, 所以每个 buffer 的最后一个 block 会保留到下一次写入.
+func cbcDecryptBlocks(dst io.Writer, src io.Reader, mode cipher.BlockMode) error {
+	bs := mode.BlockSize()
+	buf := make([]byte, cbcStreamBufferSize)
+	pending := make([]byte, 0, bs) // plaintext of the last block read
+	for {
+		n, err := io.ReadFull(src, buf)
+		last := false
+		switch {
+		case err == nil:
+		case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+			last = true
+		default:
 			return err
 		}
+		if n%bs != 0 {
+			return ErrInvalidPadding
+		}
 
-		// 解密数据
-		decrypter.CryptBlocks(dst, buffer[:n])
-		if lastline {
-			// unpad plain data
-			dst, err = pkcs7Unpad(dst, block.BlockSize())
-			if err != nil {
+		if n > 0 {
+			if _, err := dst.Write(pending); err != nil {
+				return err
+			}
+			mode.CryptBlocks(buf[:n], buf[:n])
+			if _, err := dst.Write(buf[:n-bs]); err != nil {
 				return err
 			}
+			pending = append(pending[:0], buf[n-bs:n]...)
 		}
 
-		// 分段写入文件
-		_, err = dstFile.Write(dst)
-		if err != nil {
+		if last {
+			if len(pending) == 0 {
+				return ErrCiphertextTooShort
+			}
+			plaintext, err := pkcs7Unpad(pending, bs)
+			if err != nil {
+				return err
+			}
+			_, err = dst.Write(plaintext)
 			return err
 		}
 	}
 }
+
+// AESCBCEncryptFile 使用 AES-CBC 加密 srcFile, 写入 cipherFile, 返回随机生成的 iv.
+// cipherFile 中不包含 iv.
+//
+// Deprecated: CBC 没有认证, 密文被修改无法发现, 使用 NewEncryptWriter.
+func AESCBCEncryptFile(srcFile, cipherFile *os.File, key []byte) (iv []byte, err error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	iv, err = RandomBytes(block.BlockSize())
+	if err != nil {
+		return nil, err
+	}
+	if err := cbcEncryptBlocks(cipherFile, srcFile, cipher.NewCBCEncrypter(block, iv)); err != nil {
+		return nil, err
+	}
+	return iv, nil
+}
+
+// AESCBCDecryptFile 解密 AESCBCEncryptFile 生成的 cipherFile, 写入 dstFile.
+//
+// Deprecated: 使用 NewDecryptReader.
+func AESCBCDecryptFile(dstFile, cipherFile *os.File, key, iv []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	if len(iv) != block.BlockSize() {
+		return fmt.Errorf("invalid iv size %d", len(iv))
+	}
+	return cbcDecryptBlocks(dstFile, cipherFile, cipher.NewCBCDecrypter(block, iv))
+}
@@ -6,10 +6,12 @@ package crypto
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/iotest"
 )
 
 func TestAESexample(t *testing.T) {
@@ -101,3 +103,113 @@ func TestAESFile(t *testing.T) {
 	t.Log(len(sfb), len(pfb))
 	t.Log(bytes.Equal(sfb, pfb))
 }
+
+func TestDecryptInvalidInput(t *testing.T) {
+	key := bytes.Repeat([]byte("password"), 4)
+	valid, _ := AESCBCEncrypt([]byte("this is a AES CBC test!!!"), key)
+	badPadding := bytes.Clone(valid)
+	badPadding[len(badPadding)-17] ^= 0xff // last byte of the second to last block changes the padding
+
+	tests := []struct {
+		name       string
+		decrypt    func(ciphertext, key []byte) ([]byte, error)
+		ciphertext []byte
+		want       error
+	}{
+		{"CBC empty", AESCBCDecrypt, nil, ErrCiphertextTooShort},
+		{"CBC iv only", AESCBCDecrypt, make([]byte, 16), ErrCiphertextTooShort},
+		{"CBC partial block", AESCBCDecrypt, make([]byte, 40), ErrInvalidPadding},
+		{"CBC bad padding", AESCBCDecrypt, badPadding, ErrInvalidPadding},
+		{"CTR empty", AESCTRDecrypt, nil, ErrCiphertextTooShort},
+		{"CTR short iv", AESCTRDecrypt, make([]byte, 15), ErrCiphertextTooShort},
+		{"GCM short", func(c, k []byte) ([]byte, error) { return AESGCMDecrypt(c, k) }, make([]byte, 27), ErrCiphertextTooShort},
+		{"GCM tampered", func(c, k []byte) ([]byte, error) { return AESGCMDecrypt(c, k) }, make([]byte, 28), ErrAuthFailed},
+	}
+	for _, tt := range tests {
+		if _, err := tt.decrypt(tt.ciphertext, key); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := AESCTRDecrypt(make([]byte, 16), key); err != nil {
+		t.Errorf("CTR empty plaintext: err = %v", err)
+	}
+}
+
+// countWriter counts the Write calls
+type countWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestAESCBCStream(t *testing.T) {
+	key, _ := RandomBytes(32)
+	readers := map[string]func(io.Reader) io.Reader{
+		"reader":   func(r io.Reader) io.Reader { return r },
+		"one byte": iotest.OneByteReader,
+		"half":     iotest.HalfReader,
+		"data+EOF": iotest.DataErrReader,
+		"one+EOF":  func(r io.Reader) io.Reader { return iotest.DataErrReader(iotest.OneByteReader(r)) },
+	}
+
+	for _, n := range []int{0, 1, 15, 16, 17, cbcStreamBufferSize - 1, cbcStreamBufferSize, cbcStreamBufferSize + 1, 3*cbcStreamBufferSize + 5} {
+		plaintext, _ := RandomBytes(n)
+		for name, wrap := range readers {
+			var c countWriter
+			if err := AESCBCEncryptStream(&c, wrap(bytes.NewReader(plaintext)), key); err != nil {
+				t.Fatalf("%d bytes, %s: %v", n, name, err)
+			}
+			if want := 16 + (n/16+1)*16; c.Len() != want {
+				t.Errorf("%d bytes, %s: ciphertext is %d bytes, want %d", n, name, c.Len(), want)
+			}
+			// iv + one Write per buffer
+			if want := 1 + n/cbcStreamBufferSize + 1; c.writes > want {
+				t.Errorf("%d bytes, %s: %d writes, want at most %d", n, name, c.writes, want)
+			}
+			ciphertext := c.Bytes()
+
+			// 和 AESCBCDecrypt 格式相同
+			p, err := AESCBCDecrypt(ciphertext, key)
+			if err != nil || !bytes.Equal(p, plaintext) {
+				t.Errorf("%d bytes, %s: AESCBCDecrypt() err = %v, equal = %v", n, name, err, bytes.Equal(p, plaintext))
+			}
+
+			var out bytes.Buffer
+			if err := AESCBCDecryptStream(&out, wrap(bytes.NewReader(ciphertext)), key); err != nil || !bytes.Equal(out.Bytes(), plaintext) {
+				t.Errorf("%d bytes, %s: AESCBCDecryptStream() err = %v, equal = %v", n, name, err, bytes.Equal(out.Bytes(), plaintext))
+			}
+		}
+	}
+}
+
+func TestAESCBCStreamInvalid(t *testing.T) {
+	key, _ := RandomBytes(32)
+	ciphertext, _ := AESCBCEncrypt(make([]byte, 100), key)
+
+	tests := []struct {
+		name string
+		src  io.Reader
+		want error
+	}{
+		{"empty", bytes.NewReader(nil), ErrCiphertextTooShort},
+		{"short iv", bytes.NewReader(ciphertext[:10]), ErrCiphertextTooShort},
+		{"iv only", bytes.NewReader(ciphertext[:16]), ErrCiphertextTooShort},
+		{"partial block", iotest.HalfReader(bytes.NewReader(ciphertext[:len(ciphertext)-1])), ErrInvalidPadding},
+		{"truncated block", bytes.NewReader(ciphertext[:len(ciphertext)-16]), ErrInvalidPadding},
+		{"read error", io.MultiReader(bytes.NewReader(ciphertext[:32]), iotest.ErrReader(iotest.ErrTimeout)), iotest.ErrTimeout},
+	}
+	for _, tt := range tests {
+		if err := AESCBCDecryptStream(io.Discard, tt.src, key); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if err := AESCBCEncryptStream(io.Discard, iotest.ErrReader(iotest.ErrTimeout), key); !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("encrypt read error: err = %v", err)
+	}
+}
@@ -30,6 +30,8 @@ func AESCTREncrypt(plaintext, key []byte) (ciphertext []byte, err error) {
 	return ciphertext, nil
 }
 
+// AESCTRDecrypt 解密 AESCTREncrypt 的输出, ciphertext 短于 iv 返回 ErrCiphertextTooShort.
+// NOTE: CTR 没有认证, 被修改的 ciphertext 也能 "解密" 成功, 需要认证时使用 AESCTRHMACDecrypt.
 func AESCTRDecrypt(ciphertext, key []byte) (plaintext []byte, err error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
@@ -37,6 +39,9 @@ func AESCTRDecrypt(ciphertext, key []byte) (plaintext []byte, err error) {
 	}
 
 	bsize := block.BlockSize()
+	if len(ciphertext) < bsize {
+		return nil, ErrCiphertextTooShort
+	}
 	iv, encryptedData := ciphertext[:bsize], ciphertext[bsize:]
 
 	// CTR 模式的 IV 必须是 16 字节
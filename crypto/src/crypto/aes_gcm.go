@@ -3,64 +3,149 @@ package crypto
 import (
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
-	"errors"
-	"io"
+	"fmt"
 )
 
 // VVI: 默认 NonceSize = 12, key 长度必须为 16|24|32
 // nonce(iv), additionalData 都可以公开, 但是 nonce 一定不能重复, 否则会有严重安全隐患.
 // additionalData 不参与加密, 但是参 hash tag. 所以 additionalData 不管多长都不会影响 cipher 长度, 但是影响解密.
-func AESGCMEncrypt(plaintext, key []byte) (ciphertext []byte, err error) {
-	block, err := aes.NewCipher(key)
+//
+// 输出格式: nonce | ciphertext | tag
+func AESGCMEncrypt(plaintext, key []byte, opts ...GCMOption) (ciphertext []byte, err error) {
+	g, err := NewGCM(key, opts...)
 	if err != nil {
 		return nil, err
 	}
+	return g.Seal(plaintext, opts...)
+}
 
-	// 创建GCM模式
-	aesGCM, err := cipher.NewGCM(block)
+// AESGCMDecrypt 解密 AESGCMEncrypt 的输出, opts 必须和加密时一致 (WithNonce 除外, nonce 从 ciphertext 中读取).
+func AESGCMDecrypt(ciphertext, key []byte, opts ...GCMOption) (plaintext []byte, err error) {
+	g, err := NewGCM(key, opts...)
 	if err != nil {
 		return nil, err
 	}
+	return g.Open(ciphertext, opts...)
+}
 
-	// 创建 random nonce(iv), nonce 一定不能重复, 否则会有严重安全隐患.
-	nonce := make([]byte, aesGCM.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return nil, err
-	}
+// GCMOption 修改 AES-GCM 的参数.
+// WithNonceSize, WithTagSize 只在 NewGCM 中生效, WithAAD, WithNonce 只在 Seal/Open 中生效.
+type GCMOption func(*gcmOptions)
+
+type gcmOptions struct {
+	aad       []byte
+	nonce     []byte
+	nonceSize int
+	tagSize   int
+}
+
+// WithAAD 设置 additionalData, 解密时必须提供相同的 additionalData.
+func WithAAD(aad []byte) GCMOption {
+	return func(o *gcmOptions) { o.aad = aad }
+}
+
+// WithNonce 使用指定的 nonce 代替随机 nonce, 只用于 test vectors 等确定性的场景.
+// NOTE: 同一个 key 绝对不能重复使用 nonce.
+func WithNonce(nonce []byte) GCMOption {
+	return func(o *gcmOptions) { o.nonce = nonce }
+}
+
+// WithNonceSize 设置 nonce 长度, 默认为 12 bytes. 非 12 bytes 的 nonce 只用于兼容其他实现.
+func WithNonceSize(size int) GCMOption {
+	return func(o *gcmOptions) { o.nonceSize = size }
+}
+
+// WithTagSize 设置 tag 长度, 12 到 16 bytes, 默认为 16 bytes.
+func WithTagSize(size int) GCMOption {
+	return func(o *gcmOptions) { o.tagSize = size }
+}
 
-	// 加密并附加认证标签
-	ciphertext = aesGCM.Seal(nonce, nonce, plaintext, nil)
-	// 注意：返回的ciphertext包含nonce在前面，然后是实际密文
+func newGCMOptions(opts []GCMOption) gcmOptions {
+	var o gcmOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
 
-	return ciphertext, nil
+// GCM 缓存一个 key 的 cipher.AEAD, 避免每次加密都调用 aes.NewCipher 和 cipher.NewGCM.
+// GCM 可以被多个 goroutine 同时使用.
+type GCM struct {
+	aead cipher.AEAD
 }
 
-func AESGCMDecrypt(ciphertext, key []byte) (plaintext []byte, err error) {
-	// 创建cipher块
+// NewGCM 创建 key 的 GCM, key 长度必须为 16|24|32.
+// 如果没有 WithNonceSize 但是有 WithNonce, nonce 长度为 len(nonce).
+// crypto/cipher 不支持同时修改 nonce 长度和 tag 长度.
+func NewGCM(key []byte, opts ...GCMOption) (*GCM, error) {
+	o := newGCMOptions(opts)
+	if o.nonceSize == 0 && o.nonce != nil {
+		o.nonceSize = len(o.nonce)
+	}
+	const (
+		defaultNonceSize = 12
+		defaultTagSize   = 16
+	)
+
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建GCM模式
-	aesGCM, err := cipher.NewGCM(block)
+	var aead cipher.AEAD
+	switch {
+	case o.nonceSize != 0 && o.nonceSize != defaultNonceSize && o.tagSize != 0 && o.tagSize != defaultTagSize:
+		return nil, fmt.Errorf("gcm: nonce size %d and tag size %d cannot be combined", o.nonceSize, o.tagSize)
+	case o.nonceSize != 0 && o.nonceSize != defaultNonceSize:
+		aead, err = cipher.NewGCMWithNonceSize(block, o.nonceSize)
+	case o.tagSize != 0 && o.tagSize != defaultTagSize:
+		aead, err = cipher.NewGCMWithTagSize(block, o.tagSize)
+	default:
+		aead, err = cipher.NewGCM(block)
+	}
 	if err != nil {
 		return nil, err
 	}
+	return &GCM{aead: aead}, nil
+}
+
+// NonceSize returns the size of the nonce at the beginning of the ciphertext.
+func (g *GCM) NonceSize() int { return g.aead.NonceSize() }
+
+// Overhead returns len(ciphertext) - len(plaintext).
+func (g *GCM) Overhead() int { return g.aead.NonceSize() + g.aead.Overhead() }
 
-	// 验证密文长度
-	nonceSize := aesGCM.NonceSize()
-	if len(ciphertext) < nonceSize {
-		return nil, errors.New("cipher text size error: less than nonce size")
+// Seal 加密 plaintext, 返回 nonce | ciphertext | tag. 没有 WithNonce 时使用随机 nonce.
+func (g *GCM) Seal(plaintext []byte, opts ...GCMOption) ([]byte, error) {
+	o := newGCMOptions(opts)
+	nonce := o.nonce
+	if nonce == nil {
+		var err error
+		if nonce, err = RandomBytes(g.aead.NonceSize()); err != nil {
+			return nil, err
+		}
+	}
+	if len(nonce) != g.aead.NonceSize() {
+		return nil, fmt.Errorf("gcm: nonce size %d, want %d", len(nonce), g.aead.NonceSize())
+	}
+
+	out := make([]byte, len(nonce), g.Overhead()+len(plaintext))
+	copy(out, nonce)
+	return g.aead.Seal(out, nonce, plaintext, o.aad), nil
+}
+
+// Open 解密 Seal 的输出, ciphertext 短于 nonce + tag 返回 ErrCiphertextTooShort, 认证失败返回 ErrAuthFailed.
+func (g *GCM) Open(ciphertext []byte, opts ...GCMOption) ([]byte, error) {
+	o := newGCMOptions(opts)
+	nonceSize := g.aead.NonceSize()
+	if len(ciphertext) < nonceSize+g.aead.Overhead() {
+		return nil, ErrCiphertextTooShort
 	}
 
-	// 提取nonce并解密
 	nonce, encryptedData := ciphertext[:nonceSize], ciphertext[nonceSize:]
-	plaintext, err = aesGCM.Open(nil, nonce, encryptedData, nil)
+	plaintext, err := g.aead.Open(nil, nonce, encryptedData, o.aad)
 	if err != nil {
-		return nil, err
+		return nil, ErrAuthFailed
 	}
-
 	return plaintext, nil
 }
@@ -6,6 +6,7 @@ import (
 	"crypto/cipher"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"io"
 	"testing"
 )
@@ -58,3 +59,163 @@ func TestAESGCMEncrypt2(t *testing.T) {
 
 	t.Log(bytes.Equal(plaintext, p))
 }
+
+// NIST GCM test vectors (McGrew & Viega, "The Galois/Counter Mode of Operation (GCM)", Appendix B)
+var gcmTestVectors = []struct {
+	name                    string
+	key, iv, plaintext, aad string
+	ciphertext, tag         string
+}{
+	{
+		name: "case 1",
+		key:  "00000000000000000000000000000000",
+		iv:   "000000000000000000000000",
+		tag:  "58e2fccefa7e3061367f1d57a4e7455a",
+	},
+	{
+		name:       "case 2",
+		key:        "00000000000000000000000000000000",
+		iv:         "000000000000000000000000",
+		plaintext:  "00000000000000000000000000000000",
+		ciphertext: "0388dace60b6a392f328c2b971b2fe78",
+		tag:        "ab6e47d42cec13bdf53a67b21257bddf",
+	},
+	{
+		name:       "case 3",
+		key:        "feffe9928665731c6d6a8f9467308308",
+		iv:         "cafebabefacedbaddecaf888",
+		plaintext:  "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b391aafd255",
+		ciphertext: "42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091473f5985",
+		tag:        "4d5c2af327cd64a62cf35abd2ba6fab4",
+	},
+	{
+		name:       "case 4",
+		key:        "feffe9928665731c6d6a8f9467308308",
+		iv:         "cafebabefacedbaddecaf888",
+		plaintext:  "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39",
+		aad:        "feedfacedeadbeeffeedfacedeadbeefabaddad2",
+		ciphertext: "42831ec2217774244b7221b784d0d49ce3aa212f2c02a4e035c17e2329aca12e21d514b25466931c7d8f6a5aac84aa051ba30b396a0aac973d58e091",
+		tag:        "5bc94fbc3221a5db94fae95ae7121a47",
+	},
+	{
+		name:       "case 5, 8 bytes iv",
+		key:        "feffe9928665731c6d6a8f9467308308",
+		iv:         "cafebabefacedbad",
+		plaintext:  "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39",
+		aad:        "feedfacedeadbeeffeedfacedeadbeefabaddad2",
+		ciphertext: "61353b4c2806934a777ff51fa22a4755699b2a714fcdc6f83766e5f97b6c742373806900e49f24b22b097544d4896b424989b5e1ebac0f07c23f4598",
+		tag:        "3612d2e79e3b0785561be14aaca2fccb",
+	},
+	{
+		name: "case 13",
+		key:  "0000000000000000000000000000000000000000000000000000000000000000",
+		iv:   "000000000000000000000000",
+		tag:  "530f8afbc74536b9a963b4f1c4cb738b",
+	},
+	{
+		name:       "case 14",
+		key:        "0000000000000000000000000000000000000000000000000000000000000000",
+		iv:         "000000000000000000000000",
+		plaintext:  "00000000000000000000000000000000",
+		ciphertext: "cea7403d4d606b6e074ec5d3baf39d18",
+		tag:        "d0d1c8a799996bf0265b98b5d48ab919",
+	},
+	{
+		name:       "case 15",
+		key:        "feffe9928665731c6d6a8f9467308308feffe9928665731c6d6a8f9467308308",
+		iv:         "cafebabefacedbaddecaf888",
+		plaintext:  "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b391aafd255",
+		ciphertext: "522dc1f099567d07f47f37a32a84427d643a8cdcbfe5c0c97598a2bd2555d1aa8cb08e48590dbb3da7b08b1056828838c5f61e6393ba7a0abcc9f662898015ad",
+		tag:        "b094dac5d93471bdec1a502270e3cc6c",
+	},
+	{
+		name:       "case 16",
+		key:        "feffe9928665731c6d6a8f9467308308feffe9928665731c6d6a8f9467308308",
+		iv:         "cafebabefacedbaddecaf888",
+		plaintext:  "d9313225f88406e5a55909c5aff5269a86a7a9531534f7da2e4c303d8a318a721c3c0c95956809532fcf0e2449a6b525b16aedf5aa0de657ba637b39",
+		aad:        "feedfacedeadbeeffeedfacedeadbeefabaddad2",
+		ciphertext: "522dc1f099567d07f47f37a32a84427d643a8cdcbfe5c0c97598a2bd2555d1aa8cb08e48590dbb3da7b08b1056828838c5f61e6393ba7a0abcc9f662",
+		tag:        "76fc6ece0f4e1768cddf8853bb2d551b",
+	},
+}
+
+func TestGCMKnownAnswer(t *testing.T) {
+	decode := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	for _, tv := range gcmTestVectors {
+		key, iv, plaintext, aad := decode(tv.key), decode(tv.iv), decode(tv.plaintext), decode(tv.aad)
+		tag := decode(tv.tag)
+
+		// 完整 tag 和截断的 tag (GCM 截断的 tag 是完整 tag 的前缀)
+		for _, tagSize := range []int{16, 12} {
+			if tagSize != 16 && len(iv) != 12 {
+				continue // crypto/cipher 不支持同时修改 nonce 长度和 tag 长度
+			}
+			want := append(append(bytes.Clone(iv), decode(tv.ciphertext)...), tag[:tagSize]...)
+
+			got, err := AESGCMEncrypt(plaintext, key, WithNonce(iv), WithAAD(aad), WithTagSize(tagSize))
+			if err != nil {
+				t.Fatalf("%s: %v", tv.name, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s, tag %d: AESGCMEncrypt() = %x, want %x", tv.name, tagSize, got, want)
+			}
+
+			p, err := AESGCMDecrypt(want, key, WithNonceSize(len(iv)), WithAAD(aad), WithTagSize(tagSize))
+			if err != nil || !bytes.Equal(p, plaintext) {
+				t.Errorf("%s, tag %d: AESGCMDecrypt() = %x, %v", tv.name, tagSize, p, err)
+			}
+
+			if _, err := AESGCMDecrypt(want, key, WithNonceSize(len(iv)), WithAAD([]byte("wrong")), WithTagSize(tagSize)); !errors.Is(err, ErrAuthFailed) {
+				t.Errorf("%s, tag %d: wrong aad: err = %v, want ErrAuthFailed", tv.name, tagSize, err)
+			}
+		}
+	}
+}
+
+func TestGCM(t *testing.T) {
+	key, _ := RandomBytes(32)
+	g, err := NewGCM(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("this is a AES-256-GCM test!!!")
+	aad := []byte("header")
+
+	c1, _ := g.Seal(plaintext, WithAAD(aad))
+	c2, _ := g.Seal(plaintext, WithAAD(aad))
+	if bytes.Equal(c1, c2) {
+		t.Error("random nonce is reused")
+	}
+	if len(c1) != len(plaintext)+g.Overhead() {
+		t.Errorf("len(ciphertext) = %d, want %d", len(c1), len(plaintext)+g.Overhead())
+	}
+
+	// AESGCMDecrypt 兼容 GCM.Seal
+	p, err := AESGCMDecrypt(c1, key, WithAAD(aad))
+	if err != nil || !bytes.Equal(p, plaintext) {
+		t.Errorf("AESGCMDecrypt() = %q, %v", p, err)
+	}
+	if _, err := g.Open(c1); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Open() without aad: err = %v, want ErrAuthFailed", err)
+	}
+	if _, err := g.Open(c1[:g.Overhead()-1], WithAAD(aad)); err == nil {
+		t.Error("Open() short ciphertext succeeded")
+	}
+
+	if _, err := g.Seal(plaintext, WithNonce(make([]byte, 8))); err == nil {
+		t.Error("Seal() with wrong nonce size succeeded")
+	}
+	if _, err := NewGCM(key, WithNonceSize(8), WithTagSize(12)); err == nil {
+		t.Error("NewGCM() with nonce size and tag size succeeded")
+	}
+	if _, err := NewGCM(key, WithTagSize(8)); err == nil {
+		t.Error("NewGCM() with tag size 8 succeeded")
+	}
+}
@@ -0,0 +1,104 @@
+package crypto
+
+import (
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// OWASPArgon2Params 是 OWASP Password Storage Cheat Sheet 推荐的 Argon2id 最低参数: 19 MiB, 2 iterations, 1 thread.
+// memory 不小于 46 MiB 时, OWASP 允许 1 iteration.
+var OWASPArgon2Params = Argon2Params{
+	Memory:  19 * 1024, // KB
+	Time:    2,
+	Threads: 1,
+	KeyLen:  32,
+}
+
+// owaspMinTime returns the minimum iterations of OWASP for memory.
+func owaspMinTime(memory uint32) uint32 {
+	if memory >= 46*1024 {
+		return 1
+	}
+	return OWASPArgon2Params.Time
+}
+
+// measureArgon2 returns the duration of one argon2.IDKey with p.
+func measureArgon2(p Argon2Params) time.Duration {
+	salt := make([]byte, saltLen)
+	start := time.Now()
+	argon2.IDKey([]byte("calibrate"), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return time.Since(start)
+}
+
+// CalibrateArgon2 在当前机器上测量 argon2.IDKey, 返回 target 时间内最强的参数.
+// maxMemory 单位为 KB, threads 为 0 时使用 1.
+//
+// 优先使用更多的 memory (RFC 9106, memory 比 iterations 更能对抗 GPU/ASIC):
+// 从 maxMemory 开始, 超过 target 时按测量结果 (时间和 memory*iterations 基本成正比) 估计 target 之内的 memory,
+// 直到 minimum iterations 在 target 之内, 再用剩余的时间增加 iterations.
+// 返回的参数不会低于 OWASPArgon2Params, 即使机器太慢超过了 target.
+//
+// NOTE: 每次测量都会实际计算一次 hash, maxMemory 很大时 CalibrateArgon2 本身需要几秒钟, 应该只在部署时运行一次.
+func CalibrateArgon2(target time.Duration, maxMemory uint32, threads uint8) Argon2Params {
+	return calibrateArgon2(target, maxMemory, threads, measureArgon2)
+}
+
+// calibrateArgon2 is CalibrateArgon2 with the measure function, tests use a simulated machine.
+func calibrateArgon2(target time.Duration, maxMemory uint32, threads uint8, measure func(Argon2Params) time.Duration) Argon2Params {
+	if threads == 0 {
+		threads = 1
+	}
+	p := Argon2Params{
+		Memory:  max(maxMemory, OWASPArgon2Params.Memory, 8*uint32(threads)),
+		Threads: threads,
+		KeyLen:  OWASPArgon2Params.KeyLen,
+	}
+
+	// memory
+	var d time.Duration
+	for {
+		p.Time = owaspMinTime(p.Memory)
+		d = measure(p)
+		if d <= target || p.Memory == OWASPArgon2Params.Memory {
+			break
+		}
+		p.Memory = max(min(nextMemory(p, d, target), p.Memory-1), OWASPArgon2Params.Memory)
+	}
+	if d > target {
+		return p // floor
+	}
+
+	// iterations, 时间和 iterations 基本成正比
+	perPass := d / time.Duration(p.Time)
+	if perPass <= 0 {
+		perPass = 1
+	}
+	minTime := p.Time
+	p.Time = uint32(min(int64(target/perPass), 1<<20))
+	for p.Time > minTime {
+		d := measure(p)
+		if d <= target {
+			break
+		}
+		// 按测量结果重新估计, 至少减少 1
+		next := uint32(uint64(p.Time) * uint64(target) / uint64(d))
+		p.Time = max(min(next, p.Time-1), minTime)
+	}
+	p.Time = max(p.Time, minTime)
+	return p
+}
+
+// nextMemory estimates the memory whose minimum iterations take target, p takes d.
+// 结果按 MiB 向下取整, memory 小于 46 MiB 时 minimum iterations 是 2, 所以 memory 再减半.
+func nextMemory(p Argon2Params, d, target time.Duration) uint32 {
+	work := uint64(p.Memory) * uint64(p.Time) * uint64(target) / uint64(d) // memory * iterations
+	if work >= 1<<32 {
+		return p.Memory
+	}
+	m := uint32(work) / owaspMinTime(uint32(work))
+	if m >= 1024 {
+		m -= m % 1024
+	}
+	return m
+}
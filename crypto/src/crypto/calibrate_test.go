@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalibrateArgon2(t *testing.T) {
+	// 模拟的机器: 1 MiB memory 1 iteration 需要 1ms, 多线程没有加速
+	var measured int
+	measure := func(p Argon2Params) time.Duration {
+		measured++
+		return time.Duration(p.Memory) * time.Duration(p.Time) * time.Millisecond / 1024
+	}
+
+	const MiB = 1024
+	tests := []struct {
+		name       string
+		target     time.Duration
+		maxMemory  uint32
+		threads    uint8
+		wantMemory uint32
+		wantTime   uint32
+	}{
+		{"max memory", 500 * time.Millisecond, 256 * MiB, 4, 256 * MiB, 1},
+		{"interpolate memory", 100 * time.Millisecond, 1024 * MiB, 4, 100 * MiB, 1},
+		{"interpolate below 46 MiB", 40 * time.Millisecond, 1024 * MiB, 1, 20 * MiB, 2},
+		{"more iterations", time.Second, 32 * MiB, 1, 32 * MiB, 31},
+		{"iterations after interpolation", 300 * time.Millisecond, 1024 * MiB, 2, 300 * MiB, 1},
+		{"more iterations at max memory", 300 * time.Millisecond, 128 * MiB, 2, 128 * MiB, 2},
+		{"floor", time.Millisecond, 1024 * MiB, 1, 19 * MiB, 2},
+		{"max memory below floor", time.Second, 1 * MiB, 0, 19 * MiB, 52},
+	}
+	for _, tt := range tests {
+		measured = 0
+		p := calibrateArgon2(tt.target, tt.maxMemory, tt.threads, measure)
+		if p.Memory != tt.wantMemory || p.Time != tt.wantTime {
+			t.Errorf("%s: CalibrateArgon2() = m=%d, t=%d, want m=%d, t=%d", tt.name, p.Memory, p.Time, tt.wantMemory, tt.wantTime)
+		}
+		if want := max(tt.threads, 1); p.Threads != want || p.KeyLen != 32 {
+			t.Errorf("%s: threads %d, key_len %d", tt.name, p.Threads, p.KeyLen)
+		}
+		if measured > 12 {
+			t.Errorf("%s: %d measurements", tt.name, measured)
+		}
+	}
+}
+
+// 一个 hash 比预计的慢, iterations 需要减少
+func TestCalibrateArgon2Nonlinear(t *testing.T) {
+	measure := func(p Argon2Params) time.Duration {
+		d := time.Duration(p.Memory) * time.Duration(p.Time) * time.Millisecond / 1024
+		if p.Time > 10 {
+			d *= 2
+		}
+		return d
+	}
+
+	p := calibrateArgon2(time.Second, 32*1024, 1, measure)
+	if p.Time < 2 || measure(p) > time.Second {
+		t.Errorf("calibrateArgon2() = m=%d, t=%d, %v", p.Memory, p.Time, measure(p))
+	}
+}
+
+// 每个 hash 有固定的 20ms 开销, 估计的 memory 仍然超过 target, 需要再次估计
+func TestCalibrateArgon2Overhead(t *testing.T) {
+	var measured int
+	measure := func(p Argon2Params) time.Duration {
+		measured++
+		return 20*time.Millisecond + time.Duration(p.Memory)*time.Duration(p.Time)*time.Millisecond/1024
+	}
+
+	p := calibrateArgon2(100*time.Millisecond, 1024*1024, 1, measure)
+	if d := measure(p); d > 100*time.Millisecond || p.Memory < 64*1024 || measured > 12 {
+		t.Errorf("calibrateArgon2() = m=%d, t=%d, %v, %d measurements", p.Memory, p.Time, d, measured)
+	}
+}
+
+func TestCalibrateArgon2Machine(t *testing.T) {
+	if testing.Short() {
+		t.Skip("benchmarks argon2")
+	}
+	p := CalibrateArgon2(100*time.Millisecond, 64*1024, 2)
+	t.Logf("m=%d, t=%d, p=%d", p.Memory, p.Time, p.Threads)
+	if p.Memory < OWASPArgon2Params.Memory || p.Time < owaspMinTime(p.Memory) {
+		t.Errorf("CalibrateArgon2() = %+v is below the OWASP minimum", p)
+	}
+}
@@ -0,0 +1,475 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Envelope 是 SealWithPassword 和 SealWithKDF 的输出, 包含解密需要的所有参数 (除了密码), 可以直接保存.
+// 两个 version 都使用 OpenWithPassword 解密.
+//
+// Version 1 (SealWithPassword), KDF 只能是 Argon2id:
+//
+//	{"version":1,"kdf":"argon2id","argon2id":{"version":19,"memory":65536,"time":3,"threads":4,"key_len":32,"salt":"..."},
+//	 "cipher":"aes-256-gcm","nonce":"...","ciphertext":"..."}
+//
+// Version 2 (SealWithKDF), KDF 可以是任何注册的 KDF (参考 DecodeKDF), 参数以 JSON 保存:
+//
+//	{"version":2,"kdf":"scrypt","kdf_params":{"n":131072,"r":8,"p":1,"key_len":32},"salt":"...",
+//	 "cipher":"aes-256-gcm","nonce":"...","ciphertext":"..."}
+//
+// Binary (MarshalBinary), 整数都是 big endian:
+//
+//	version 1: magic "PWENV" | version u8 | kdf u8 | argon2 version u8 | memory u32 | time u32 | threads u8 | key_len u32 |
+//	           salt_len u8 | salt | cipher u8 | nonce_len u8 | nonce | ciphertext
+//	version 2: magic "PWENV" | version u8 | kdf_len u8 | kdf | params_len u16 | params JSON | salt_len u8 | salt |
+//	           cipher u8 | nonce_len u8 | nonce | ciphertext
+//
+// 除 ciphertext 以外的部分 (header) 作为 GCM 的 additionalData, 所以修改 KDF 参数或者 cipher 也会导致认证失败.
+// version 2 的 params 使用 KDF.Params() 重新编码之后的 JSON, 所以 JSON 的格式 (空格, 字段顺序) 不影响认证.
+type Envelope struct {
+	Version       int             `json:"version"`
+	KDF           string          `json:"kdf"`
+	Argon2id      *Argon2Env      `json:"argon2id,omitempty"`   // version 1
+	KDFParams     json.RawMessage `json:"kdf_params,omitempty"` // version 2
+	SaltHex       string          `json:"salt,omitempty"`       // version 2
+	Cipher        string          `json:"cipher"`
+	NonceHex      string          `json:"nonce"`
+	CiphertextHex string          `json:"ciphertext"`
+}
+
+const (
+	EnvelopeVersion    = 1 // Argon2id, SealWithPassword
+	EnvelopeVersionKDF = 2 // any registered KDF, SealWithKDF
+
+	KDFArgon2id     = "argon2id"
+	CipherAES256GCM = "aes-256-gcm"
+
+	envelopeMagic = "PWENV"
+)
+
+// ids in the binary encoding
+var (
+	kdfIDs    = map[string]byte{KDFArgon2id: 1}
+	cipherIDs = map[string]byte{CipherAES256GCM: 1}
+)
+
+// DefaultArgon2Params 是 SealWithPassword 的默认参数
+var DefaultArgon2Params = Argon2Params{
+	Memory:  64 * 1024, // 64 MB (单位为 KB)
+	Time:    3,         // iterations
+	Threads: 4,
+	KeyLen:  32,
+}
+
+// SealWithPassword 使用 Argon2id 从 password 派生 key, 再用 AES-256-GCM 加密 plaintext.
+// params 为 nil 时使用 DefaultArgon2Params, AES-256 需要 32 bytes key, 所以 params.KeyLen 会被忽略.
+// params 超出 DefaultKDFPolicy() 返回 ErrKDFPolicy, 否则 OpenWithPassword 无法解密.
+func SealWithPassword(password, plaintext []byte, params *Argon2Params) (*Envelope, error) {
+	return defaultKDFPolicy.SealWithPassword(password, plaintext, params)
+}
+
+// SealWithPassword 和 SealWithPassword 函数相同, 但是使用 p 检查 params, 解密时使用 p.OpenWithPassword.
+func (p *KDFPolicy) SealWithPassword(password, plaintext []byte, params *Argon2Params) (*Envelope, error) {
+	p = p.orDefault()
+	ap := DefaultArgon2Params
+	if params != nil {
+		ap = *params
+	}
+	ap.KeyLen = 32
+	if err := p.check(argon2.Version, &ap, saltLen); err != nil {
+		return nil, err
+	}
+
+	key, kdfEnv, err := Argon2id(password, &ap, nil)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := RandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{
+		Version:  EnvelopeVersion,
+		KDF:      KDFArgon2id,
+		Argon2id: kdfEnv,
+		Cipher:   CipherAES256GCM,
+		NonceHex: hex.EncodeToString(nonce),
+	}
+	header, err := env.header()
+	if err != nil {
+		return nil, err
+	}
+	env.CiphertextHex = hex.EncodeToString(aead.Seal(nil, nonce, plaintext, header))
+	return env, nil
+}
+
+// SealWithKDF 使用 kdf 从 password 派生 key, 再用 AES-256-GCM 加密 plaintext, 返回 version 2 的 Envelope.
+// kdf 派生的 key 必须是 32 bytes.
+func SealWithKDF(kdf KDF, password, plaintext []byte) (*Envelope, error) {
+	if n, ok := kdfKeyLen(kdf); ok && n != 32 {
+		return nil, fmt.Errorf("%w: %s key length %d, want 32", ErrUnsupportedCipher, kdf.ID(), n)
+	}
+	params, err := json.Marshal(kdf.Params())
+	if err != nil {
+		return nil, err
+	}
+	salt, err := RandomBytes(saltLen)
+	if err != nil {
+		return nil, err
+	}
+	key, err := kdf.Derive(password, salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 { // KDFs other than the built-in ones
+		return nil, fmt.Errorf("%w: %s key length %d, want 32", ErrUnsupportedCipher, kdf.ID(), len(key))
+	}
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := RandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	env := &Envelope{
+		Version:   EnvelopeVersionKDF,
+		KDF:       kdf.ID(),
+		KDFParams: params,
+		SaltHex:   hex.EncodeToString(salt),
+		Cipher:    CipherAES256GCM,
+		NonceHex:  hex.EncodeToString(nonce),
+	}
+	header, err := env.header()
+	if err != nil {
+		return nil, err
+	}
+	env.CiphertextHex = hex.EncodeToString(aead.Seal(nil, nonce, plaintext, header))
+	return env, nil
+}
+
+// OpenWithPassword 解密 SealWithPassword 和 SealWithKDF 生成的 Envelope.
+// 未知的 version, KDF, cipher 分别返回 ErrUnsupportedVersion, ErrUnsupportedKDF, ErrUnsupportedCipher,
+// KDF 参数超出 DefaultKDFPolicy() 返回 ErrKDFPolicy, 密码错误或者 envelope 被修改返回 ErrAuthFailed.
+func OpenWithPassword(password []byte, env *Envelope) ([]byte, error) {
+	return defaultKDFPolicy.OpenWithPassword(password, env)
+}
+
+// OpenWithPassword 和 OpenWithPassword 函数相同, 但是使用 p 检查 KDF 参数.
+// version 2 的 KDF 是内置的 KDF 时使用 p, 其他注册的 KDF 自己检查参数.
+func (p *KDFPolicy) OpenWithPassword(password []byte, env *Envelope) ([]byte, error) {
+	p = p.orDefault()
+	kdf, salt, header, err := env.decode()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(env.NonceHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: nonce: %w", ErrMalformedEnvelope, err)
+	}
+	ciphertext, err := hex.DecodeString(env.CiphertextHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ciphertext: %w", ErrMalformedEnvelope, err)
+	}
+	var key []byte
+	if kdf == nil {
+		key, err = p.Argon2id(password, env.Argon2id)
+	} else {
+		setKDFPolicy(kdf, p)
+		key, err = kdf.Derive(password, salt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 { // checked by decode() for the built-in KDFs
+		return nil, fmt.Errorf("%w: %s key length %d", ErrMalformedEnvelope, env.KDF, len(key))
+	}
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: nonce size %d", ErrMalformedEnvelope, len(nonce))
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, header)
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// header checks the envelope and returns the binary encoding without ciphertext.
+func (e *Envelope) header() ([]byte, error) {
+	_, _, header, err := e.decode()
+	return header, err
+}
+
+// decode checks the envelope and returns the header,
+// for version 2 it also returns the KDF and salt, the KDF is nil for version 1.
+func (e *Envelope) decode() (kdf KDF, salt, header []byte, err error) {
+	switch e.Version {
+	case EnvelopeVersion:
+		header, err = e.headerV1()
+		return nil, nil, header, err
+	case EnvelopeVersionKDF:
+		return e.decodeV2()
+	}
+	return nil, nil, nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, e.Version)
+}
+
+func (e *Envelope) headerV1() ([]byte, error) {
+	if e.KDFParams != nil || e.SaltHex != "" {
+		return nil, fmt.Errorf("%w: version 1 with kdf_params or salt", ErrMalformedEnvelope)
+	}
+	kdf, ok := kdfIDs[e.KDF]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKDF, e.KDF)
+	}
+	c, ok := cipherIDs[e.Cipher]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedCipher, e.Cipher)
+	}
+
+	a := e.Argon2id
+	if a == nil {
+		return nil, fmt.Errorf("%w: missing argon2id parameters", ErrMalformedEnvelope)
+	}
+	if a.Version < 0 || a.Version > 0xff {
+		return nil, fmt.Errorf("%w: argon2 version %d", ErrUnsupportedKDF, a.Version)
+	}
+	if a.Time < 1 || a.Threads < 1 || a.KeyLen != 32 {
+		return nil, fmt.Errorf("%w: argon2id time %d, threads %d, key_len %d", ErrMalformedEnvelope, a.Time, a.Threads, a.KeyLen)
+	}
+	salt, err := hex.DecodeString(a.SaltHex)
+	if err != nil || len(salt) > 0xff {
+		return nil, fmt.Errorf("%w: salt %q", ErrMalformedEnvelope, a.SaltHex)
+	}
+	nonce, err := hex.DecodeString(e.NonceHex)
+	if err != nil || len(nonce) > 0xff {
+		return nil, fmt.Errorf("%w: nonce %q", ErrMalformedEnvelope, e.NonceHex)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(envelopeMagic)
+	b.WriteByte(byte(e.Version))
+	b.WriteByte(kdf)
+	b.WriteByte(byte(a.Version))
+	b.Write(binary.BigEndian.AppendUint32(nil, a.Memory))
+	b.Write(binary.BigEndian.AppendUint32(nil, a.Time))
+	b.WriteByte(a.Threads)
+	b.Write(binary.BigEndian.AppendUint32(nil, a.KeyLen))
+	b.WriteByte(byte(len(salt)))
+	b.Write(salt)
+	b.WriteByte(c)
+	b.WriteByte(byte(len(nonce)))
+	b.Write(nonce)
+	return b.Bytes(), nil
+}
+
+func (e *Envelope) decodeV2() (KDF, []byte, []byte, error) {
+	if e.Argon2id != nil {
+		return nil, nil, nil, fmt.Errorf("%w: version 2 with argon2id parameters, use kdf_params", ErrMalformedEnvelope)
+	}
+	c, ok := cipherIDs[e.Cipher]
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedCipher, e.Cipher)
+	}
+	kdf, err := DecodeKDF(e.KDF, e.KDFParams)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// AES-256 key, checked before Derive
+	if n, ok := kdfKeyLen(kdf); ok && n != 32 {
+		return nil, nil, nil, fmt.Errorf("%w: %s key length %d", ErrMalformedEnvelope, e.KDF, n)
+	}
+	params, err := json.Marshal(kdf.Params())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	salt, err := hex.DecodeString(e.SaltHex)
+	if err != nil || len(salt) > 0xff {
+		return nil, nil, nil, fmt.Errorf("%w: salt %q", ErrMalformedEnvelope, e.SaltHex)
+	}
+	nonce, err := hex.DecodeString(e.NonceHex)
+	if err != nil || len(nonce) > 0xff {
+		return nil, nil, nil, fmt.Errorf("%w: nonce %q", ErrMalformedEnvelope, e.NonceHex)
+	}
+	if len(e.KDF) > 0xff || len(params) > 0xffff {
+		return nil, nil, nil, fmt.Errorf("%w: kdf %q parameters too long", ErrMalformedEnvelope, e.KDF)
+	}
+
+	var b bytes.Buffer
+	b.WriteString(envelopeMagic)
+	b.WriteByte(byte(e.Version))
+	b.WriteByte(byte(len(e.KDF)))
+	b.WriteString(e.KDF)
+	b.Write(binary.BigEndian.AppendUint16(nil, uint16(len(params))))
+	b.Write(params)
+	b.WriteByte(byte(len(salt)))
+	b.Write(salt)
+	b.WriteByte(c)
+	b.WriteByte(byte(len(nonce)))
+	b.Write(nonce)
+	return kdf, salt, b.Bytes(), nil
+}
+
+// MarshalBinary encodes the envelope in the compact binary format.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	header, err := e.header()
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := hex.DecodeString(e.CiphertextHex)
+	if err != nil {
+		return nil, fmt.Errorf("%w: ciphertext: %w", ErrMalformedEnvelope, err)
+	}
+	return append(header, ciphertext...), nil
+}
+
+// UnmarshalBinary decodes the binary format, unknown version or algorithms are rejected.
+func (e *Envelope) UnmarshalBinary(data []byte) error {
+	r := envelopeReader{data: data}
+	if string(r.next(len(envelopeMagic))) != envelopeMagic {
+		return fmt.Errorf("%w: bad magic", ErrMalformedEnvelope)
+	}
+
+	version := int(r.byte())
+	if r.err == nil && version == EnvelopeVersionKDF {
+		return e.unmarshalV2(&r)
+	}
+	if r.err == nil && version != EnvelopeVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	id := r.byte()
+	kdf, ok := idName(kdfIDs, id)
+	if r.err == nil && !ok {
+		return fmt.Errorf("%w: id %d", ErrUnsupportedKDF, id)
+	}
+
+	a := &Argon2Env{Version: int(r.byte())}
+	a.Memory = r.uint32()
+	a.Time = r.uint32()
+	a.Threads = r.byte()
+	a.KeyLen = r.uint32()
+	a.SaltHex = hex.EncodeToString(r.next(int(r.byte())))
+
+	id = r.byte()
+	c, ok := idName(cipherIDs, id)
+	if r.err == nil && !ok {
+		return fmt.Errorf("%w: id %d", ErrUnsupportedCipher, id)
+	}
+	nonce := r.next(int(r.byte()))
+	if r.err != nil {
+		return r.err
+	}
+
+	*e = Envelope{
+		Version:       version,
+		KDF:           kdf,
+		Argon2id:      a,
+		Cipher:        c,
+		NonceHex:      hex.EncodeToString(nonce),
+		CiphertextHex: hex.EncodeToString(r.data),
+	}
+	return nil
+}
+
+// unmarshalV2 decodes the version 2 binary format after the version byte.
+func (e *Envelope) unmarshalV2(r *envelopeReader) error {
+	kdf := string(r.next(int(r.byte())))
+	params := bytes.Clone(r.next(int(r.uint16())))
+	salt := r.next(int(r.byte()))
+	id := r.byte()
+	c, ok := idName(cipherIDs, id)
+	if r.err == nil && !ok {
+		return fmt.Errorf("%w: id %d", ErrUnsupportedCipher, id)
+	}
+	nonce := r.next(int(r.byte()))
+	if r.err != nil {
+		return r.err
+	}
+	if _, err := DecodeKDF(kdf, params); err != nil {
+		return err
+	}
+
+	*e = Envelope{
+		Version:       EnvelopeVersionKDF,
+		KDF:           kdf,
+		KDFParams:     params,
+		SaltHex:       hex.EncodeToString(salt),
+		Cipher:        c,
+		NonceHex:      hex.EncodeToString(nonce),
+		CiphertextHex: hex.EncodeToString(r.data),
+	}
+	return nil
+}
+
+// idName returns the name of id in the binary encoding.
+func idName(ids map[string]byte, id byte) (string, bool) {
+	for name, v := range ids {
+		if v == id {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// envelopeReader reads the binary format, err is set if data is too short.
+type envelopeReader struct {
+	data []byte
+	err  error
+}
+
+func (r *envelopeReader) next(n int) []byte {
+	if r.err != nil || len(r.data) < n {
+		r.err = fmt.Errorf("%w: truncated", ErrMalformedEnvelope)
+		return nil
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *envelopeReader) byte() byte {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *envelopeReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *envelopeReader) uint32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.BigEndian.Uint32(b)
+	}
+	return 0
+}
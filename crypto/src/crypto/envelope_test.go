@@ -0,0 +1,154 @@
+package crypto_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"local/src/crypto"
+)
+
+// fastParams 减少计算量, 只用于测试
+var fastParams = &crypto.Argon2Params{
+	Memory:  64, // KB
+	Time:    1,
+	Threads: 1,
+}
+
+func TestSealWithPassword(t *testing.T) {
+	password := []byte("password")
+	plaintext := []byte("this is a envelope test!!!")
+
+	env, err := crypto.SealWithPassword(password, plaintext, fastParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JSON
+	je, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(je))
+
+	var fromJSON crypto.Envelope
+	if err := json.Unmarshal(je, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	got, err := crypto.OpenWithPassword(password, &fromJSON)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("OpenWithPassword(JSON) = %q, %v", got, err)
+	}
+
+	// binary
+	bin, err := env.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bin) >= len(je) {
+		t.Errorf("binary %d bytes, JSON %d bytes", len(bin), len(je))
+	}
+
+	var fromBinary crypto.Envelope
+	if err := fromBinary.UnmarshalBinary(bin); err != nil {
+		t.Fatal(err)
+	}
+	got, err = crypto.OpenWithPassword(password, &fromBinary)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("OpenWithPassword(binary) = %q, %v", got, err)
+	}
+
+	// wrong password
+	if _, err := crypto.OpenWithPassword([]byte("wrong"), env); !errors.Is(err, crypto.ErrAuthFailed) {
+		t.Errorf("wrong password: err = %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestOpenWithPasswordTampered(t *testing.T) {
+	password := []byte("password")
+	env, err := crypto.SealWithPassword(password, []byte("secret"), fastParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, err := env.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]func(e *crypto.Envelope){
+		"memory": func(e *crypto.Envelope) { e.Argon2id.Memory++ },
+		"salt":   func(e *crypto.Envelope) { e.Argon2id.SaltHex = "00" + e.Argon2id.SaltHex[2:] },
+		"nonce":  func(e *crypto.Envelope) { e.NonceHex = "00" + e.NonceHex[2:] },
+		"cipher": func(e *crypto.Envelope) { e.CiphertextHex = "00" + e.CiphertextHex[2:] },
+	}
+	for name, tamper := range tests {
+		var e crypto.Envelope
+		if err := e.UnmarshalBinary(bin); err != nil {
+			t.Fatal(err)
+		}
+		tamper(&e)
+		if _, err := crypto.OpenWithPassword(password, &e); !errors.Is(err, crypto.ErrAuthFailed) {
+			t.Errorf("%s: err = %v, want ErrAuthFailed", name, err)
+		}
+	}
+}
+
+func TestEnvelopeUnsupported(t *testing.T) {
+	env, err := crypto.SealWithPassword([]byte("password"), []byte("secret"), fastParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(e crypto.Envelope) crypto.Envelope
+		want   error
+	}{
+		{"version", func(e crypto.Envelope) crypto.Envelope { e.Version = 3; return e }, crypto.ErrUnsupportedVersion},
+		{"version 2", func(e crypto.Envelope) crypto.Envelope { e.Version = crypto.EnvelopeVersionKDF; return e }, crypto.ErrMalformedEnvelope},
+		{"kdf", func(e crypto.Envelope) crypto.Envelope { e.KDF = "scrypt"; return e }, crypto.ErrUnsupportedKDF},
+		{"cipher", func(e crypto.Envelope) crypto.Envelope { e.Cipher = "aes-256-cbc"; return e }, crypto.ErrUnsupportedCipher},
+		{"no argon2id", func(e crypto.Envelope) crypto.Envelope { e.Argon2id = nil; return e }, crypto.ErrMalformedEnvelope},
+		{"nonce", func(e crypto.Envelope) crypto.Envelope { e.NonceHex = "xyz"; return e }, crypto.ErrMalformedEnvelope},
+	}
+	for _, tt := range tests {
+		e := tt.modify(*env)
+		if _, err := crypto.OpenWithPassword([]byte("password"), &e); !errors.Is(err, tt.want) {
+			t.Errorf("%s: OpenWithPassword() err = %v, want %v", tt.name, err, tt.want)
+		}
+		if _, err := e.MarshalBinary(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: MarshalBinary() err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	bin, err := env.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	binaryTests := []struct {
+		name   string
+		offset int // magic "PWENV" | version | kdf | argon2 version | memory | time | threads | key_len | salt_len | salt | cipher
+		want   error
+	}{
+		{"magic", 0, crypto.ErrMalformedEnvelope},
+		{"version", 5, crypto.ErrUnsupportedVersion},
+		{"kdf", 6, crypto.ErrUnsupportedKDF},
+		{"cipher", 5 + 1 + 1 + 1 + 4 + 4 + 1 + 4 + 1 + 16, crypto.ErrUnsupportedCipher},
+	}
+	for _, tt := range binaryTests {
+		b := bytes.Clone(bin)
+		b[tt.offset] = 0xff
+		var e crypto.Envelope
+		if err := e.UnmarshalBinary(b); !errors.Is(err, tt.want) {
+			t.Errorf("%s: UnmarshalBinary() err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	for n := range 40 {
+		var e crypto.Envelope
+		if err := e.UnmarshalBinary(bin[:n]); !errors.Is(err, crypto.ErrMalformedEnvelope) {
+			t.Errorf("truncated %d bytes: err = %v, want ErrMalformedEnvelope", n, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package crypto
+
+import "errors"
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported envelope version")
+	ErrUnsupportedKDF     = errors.New("unsupported KDF")
+	ErrUnsupportedCipher  = errors.New("unsupported cipher")
+	ErrMalformedEnvelope  = errors.New("malformed envelope")
+
+	ErrInvalidStreamHeader = errors.New("invalid stream header")
+	ErrInvalidHash         = errors.New("invalid password hash")
+	ErrKDFPolicy           = errors.New("KDF parameters rejected by policy")
+
+	// ErrCiphertextTooShort 表示 ciphertext 比 iv/nonce (+ tag) 还短.
+	ErrCiphertextTooShort = errors.New("ciphertext too short")
+	// ErrInvalidPadding 表示 CBC 解密后的 PKCS#7 padding 不正确, 或者 ciphertext 不是 block size 的倍数.
+	// NOTE: 没有 MAC 的 CBC 仍然存在 padding oracle, 需要认证时使用 AESCBCHMACDecrypt.
+	ErrInvalidPadding = errors.New("invalid padding")
+
+	// ErrAuthFailed 表示认证失败: 密码/key 错误, 或者密文被修改. 两者无法区分, 也不应该区分.
+	ErrAuthFailed = errors.New("message authentication failed")
+)
@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// Encrypt-then-MAC: 先用 AES-CBC/CTR 加密, 再对 iv+ciphertext 计算 HMAC-SHA256.
+// 解密时先验证 MAC, 验证通过之后才会解密和 unpad, 所以不存在 padding oracle.
+//
+// 加密 key 和 MAC key 不能是同一个, 两者都通过 HKDF-SHA256 从 key 派生, info 区分 CBC 和 CTR,
+// 所以 CBC 的密文不能用 CTR 解密, 反之亦然.
+//
+// Format:
+//
+//	iv (16 bytes) | ciphertext | tag (32 bytes)
+const (
+	etmInfoCBC = "aes-cbc-hmac-sha256"
+	etmInfoCTR = "aes-ctr-hmac-sha256"
+	etmTagSize = sha256.Size
+)
+
+// AESCBCHMACEncrypt 使用 AES-CBC 加密 plaintext, 并附加 HMAC-SHA256 tag.
+// key 必须是 16, 24 或 32 bytes, 派生的加密 key 和 key 长度相同.
+func AESCBCHMACEncrypt(plaintext, key []byte) ([]byte, error) {
+	block, macKey, err := etmKeys(key, etmInfoCBC)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := RandomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	padded := pkcs7Pad(plaintext, aes.BlockSize)
+	out := make([]byte, aes.BlockSize+len(padded), aes.BlockSize+len(padded)+etmTagSize)
+	copy(out, iv)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out[aes.BlockSize:], padded)
+	return etmSign(out, macKey), nil
+}
+
+// AESCBCHMACDecrypt 验证并解密 AESCBCHMACEncrypt 的输出.
+// 所有错误 (长度错误, MAC 错误, padding 错误) 都返回 ErrAuthFailed.
+func AESCBCHMACDecrypt(ciphertext, key []byte) ([]byte, error) {
+	block, macKey, err := etmKeys(key, etmInfoCBC)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aes.BlockSize+aes.BlockSize+etmTagSize {
+		return nil, ErrAuthFailed
+	}
+	data, ok := etmVerify(ciphertext, macKey)
+	if !ok || len(data)%aes.BlockSize != 0 {
+		return nil, ErrAuthFailed
+	}
+
+	iv, encrypted := data[:aes.BlockSize], data[aes.BlockSize:]
+	padded := make([]byte, len(encrypted))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(padded, encrypted)
+	plaintext, err := pkcs7Unpad(padded, aes.BlockSize)
+	if err != nil {
+		return nil, ErrAuthFailed
+	}
+	return plaintext, nil
+}
+
+// AESCTRHMACEncrypt 使用 AES-CTR 加密 plaintext, 并附加 HMAC-SHA256 tag.
+// key 必须是 16, 24 或 32 bytes.
+func AESCTRHMACEncrypt(plaintext, key []byte) ([]byte, error) {
+	block, macKey, err := etmKeys(key, etmInfoCTR)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := RandomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, aes.BlockSize+len(plaintext), aes.BlockSize+len(plaintext)+etmTagSize)
+	copy(out, iv)
+	cipher.NewCTR(block, iv).XORKeyStream(out[aes.BlockSize:], plaintext)
+	return etmSign(out, macKey), nil
+}
+
+// AESCTRHMACDecrypt 验证并解密 AESCTRHMACEncrypt 的输出, 所有错误都返回 ErrAuthFailed.
+func AESCTRHMACDecrypt(ciphertext, key []byte) ([]byte, error) {
+	block, macKey, err := etmKeys(key, etmInfoCTR)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aes.BlockSize+etmTagSize {
+		return nil, ErrAuthFailed
+	}
+	data, ok := etmVerify(ciphertext, macKey)
+	if !ok {
+		return nil, ErrAuthFailed
+	}
+
+	iv, encrypted := data[:aes.BlockSize], data[aes.BlockSize:]
+	plaintext := make([]byte, len(encrypted))
+	cipher.NewCTR(block, iv).XORKeyStream(plaintext, encrypted)
+	return plaintext, nil
+}
+
+// etmKeys derives the encryption key and the MAC key from key.
+func etmKeys(key []byte, info string) (cipher.Block, []byte, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, nil, aes.KeySizeError(len(key))
+	}
+	okm, err := hkdf.Key(sha256.New, key, nil, info, len(key)+sha256.Size)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := aes.NewCipher(okm[:len(key)])
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, okm[len(key):], nil
+}
+
+// etmSign appends the tag of data.
+func etmSign(data, macKey []byte) []byte {
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(data)
+	return mac.Sum(data)
+}
+
+// etmVerify checks the tag at the end of ciphertext in constant time, and returns iv+ciphertext.
+func etmVerify(ciphertext, macKey []byte) ([]byte, bool) {
+	data, tag := ciphertext[:len(ciphertext)-etmTagSize], ciphertext[len(ciphertext)-etmTagSize:]
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(data)
+	return data, hmac.Equal(mac.Sum(nil), tag)
+}
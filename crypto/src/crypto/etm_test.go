@@ -0,0 +1,77 @@
+package crypto_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"local/src/crypto"
+)
+
+var etmModes = []struct {
+	name    string
+	encrypt func(plaintext, key []byte) ([]byte, error)
+	decrypt func(ciphertext, key []byte) ([]byte, error)
+}{
+	{"CBC", crypto.AESCBCHMACEncrypt, crypto.AESCBCHMACDecrypt},
+	{"CTR", crypto.AESCTRHMACEncrypt, crypto.AESCTRHMACDecrypt},
+}
+
+func TestAESHMAC(t *testing.T) {
+	for _, mode := range etmModes {
+		for _, keyLen := range []int{16, 24, 32} {
+			key, _ := crypto.RandomBytes(keyLen)
+			for _, n := range []int{0, 1, 15, 16, 17, 100} {
+				plaintext, _ := crypto.RandomBytes(n)
+				ciphertext, err := mode.encrypt(plaintext, key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := mode.decrypt(ciphertext, key)
+				if err != nil || !bytes.Equal(got, plaintext) {
+					t.Errorf("%s key %d, %d bytes: decrypt = %x, %v", mode.name, keyLen, n, got, err)
+				}
+			}
+		}
+
+		if _, err := mode.encrypt([]byte("data"), []byte("short key")); err == nil {
+			t.Errorf("%s: encrypt with invalid key succeeded", mode.name)
+		}
+	}
+}
+
+func TestAESHMACTampered(t *testing.T) {
+	key, _ := crypto.RandomBytes(32)
+	otherKey, _ := crypto.RandomBytes(32)
+	plaintext := []byte("this is a encrypt-then-MAC test!!!")
+
+	for _, mode := range etmModes {
+		ciphertext, err := mode.encrypt(plaintext, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// every byte: iv, ciphertext and tag
+		for i := range ciphertext {
+			b := bytes.Clone(ciphertext)
+			b[i] ^= 0x80
+			if _, err := mode.decrypt(b, key); !errors.Is(err, crypto.ErrAuthFailed) {
+				t.Errorf("%s: flip byte %d: err = %v, want ErrAuthFailed", mode.name, i, err)
+			}
+		}
+		for n := range len(ciphertext) {
+			if _, err := mode.decrypt(ciphertext[:n], key); !errors.Is(err, crypto.ErrAuthFailed) {
+				t.Errorf("%s: truncated to %d bytes: err = %v, want ErrAuthFailed", mode.name, n, err)
+			}
+		}
+		if _, err := mode.decrypt(ciphertext, otherKey); !errors.Is(err, crypto.ErrAuthFailed) {
+			t.Errorf("%s: wrong key: err = %v, want ErrAuthFailed", mode.name, err)
+		}
+	}
+
+	// CBC 和 CTR 使用不同的派生 key
+	ciphertext, _ := crypto.AESCBCHMACEncrypt(plaintext, key)
+	if _, err := crypto.AESCTRHMACDecrypt(ciphertext, key); !errors.Is(err, crypto.ErrAuthFailed) {
+		t.Errorf("CBC ciphertext decrypted by CTR: err = %v, want ErrAuthFailed", err)
+	}
+}
@@ -0,0 +1,134 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// 所有 decrypt 函数对任意输入都不能 panic, 只能返回下面的错误.
+// go test -fuzz=FuzzAESCBCDecrypt -fuzztime=30s
+
+var fuzzKey = bytes.Repeat([]byte("password"), 4)
+
+// checkDecryptError fails if err is not nil and not one of want or an invalid key error.
+func checkDecryptError(t *testing.T, err error, want ...error) {
+	t.Helper()
+	if err == nil {
+		return
+	}
+	var keyErr aes.KeySizeError
+	if errors.As(err, &keyErr) {
+		return
+	}
+	for _, w := range want {
+		if errors.Is(err, w) {
+			return
+		}
+	}
+	t.Errorf("unexpected error: %v", err)
+}
+
+// addSeeds adds the output of encrypt for plaintexts of different sizes and some short inputs.
+func addSeeds(f *testing.F, encrypt func(plaintext, key []byte) ([]byte, error)) {
+	for _, n := range []int{0, 1, 15, 16, 17, 64} {
+		c, err := encrypt(bytes.Repeat([]byte{'a'}, n), fuzzKey)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(c, fuzzKey)
+		f.Add(c[:len(c)-1], fuzzKey)
+	}
+	for _, n := range []int{0, 1, 15, 16, 17, 31, 32, 33} {
+		f.Add(make([]byte, n), fuzzKey)
+	}
+	f.Add([]byte("ciphertext"), []byte("short key"))
+}
+
+func FuzzAESCBCDecrypt(f *testing.F) {
+	addSeeds(f, AESCBCEncrypt)
+	f.Fuzz(func(t *testing.T, ciphertext, key []byte) {
+		_, err := AESCBCDecrypt(ciphertext, key)
+		checkDecryptError(t, err, ErrCiphertextTooShort, ErrInvalidPadding)
+	})
+}
+
+func FuzzAESCTRDecrypt(f *testing.F) {
+	addSeeds(f, AESCTREncrypt)
+	f.Fuzz(func(t *testing.T, ciphertext, key []byte) {
+		p, err := AESCTRDecrypt(ciphertext, key)
+		checkDecryptError(t, err, ErrCiphertextTooShort)
+		if err == nil && len(p) != len(ciphertext)-aes.BlockSize {
+			t.Errorf("len(plaintext) = %d, len(ciphertext) = %d", len(p), len(ciphertext))
+		}
+	})
+}
+
+func FuzzAESGCMDecrypt(f *testing.F) {
+	addSeeds(f, func(plaintext, key []byte) ([]byte, error) { return AESGCMEncrypt(plaintext, key) })
+	f.Fuzz(func(t *testing.T, ciphertext, key []byte) {
+		_, err := AESGCMDecrypt(ciphertext, key)
+		checkDecryptError(t, err, ErrCiphertextTooShort, ErrAuthFailed)
+	})
+}
+
+func FuzzAESCBCHMACDecrypt(f *testing.F) {
+	addSeeds(f, AESCBCHMACEncrypt)
+	f.Fuzz(func(t *testing.T, ciphertext, key []byte) {
+		_, err := AESCBCHMACDecrypt(ciphertext, key)
+		checkDecryptError(t, err, ErrAuthFailed)
+	})
+}
+
+func FuzzAESCTRHMACDecrypt(f *testing.F) {
+	addSeeds(f, AESCTRHMACEncrypt)
+	f.Fuzz(func(t *testing.T, ciphertext, key []byte) {
+		_, err := AESCTRHMACDecrypt(ciphertext, key)
+		checkDecryptError(t, err, ErrAuthFailed)
+	})
+}
+
+func FuzzDecryptReader(f *testing.F) {
+	addSeeds(f, func(plaintext, key []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		w, err := NewEncryptWriter(&buf, key)
+		if err != nil {
+			return nil, err
+		}
+		w.Write(plaintext)
+		err = w.Close()
+		return buf.Bytes(), err
+	})
+	f.Fuzz(func(t *testing.T, ciphertext, key []byte) {
+		r, err := NewDecryptReader(bytes.NewReader(ciphertext), key)
+		if err == nil {
+			_, err = io.ReadAll(r)
+		}
+		checkDecryptError(t, err, ErrInvalidStreamHeader, ErrUnsupportedVersion, ErrAuthFailed)
+	})
+}
+
+func FuzzEnvelopeUnmarshalBinary(f *testing.F) {
+	env, err := SealWithPassword([]byte("password"), []byte("secret"), &Argon2Params{Memory: 64, Time: 1, Threads: 1})
+	if err != nil {
+		f.Fatal(err)
+	}
+	bin, err := env.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(bin)
+	f.Add(bin[:20])
+	f.Add([]byte(envelopeMagic))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var e Envelope
+		err := e.UnmarshalBinary(data)
+		checkDecryptError(t, err, ErrMalformedEnvelope, ErrUnsupportedVersion, ErrUnsupportedKDF, ErrUnsupportedCipher)
+		if err == nil {
+			// header() must accept or reject the decoded envelope without panic
+			e.MarshalBinary()
+		}
+	})
+}
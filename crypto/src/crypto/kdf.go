@@ -0,0 +1,246 @@
+package crypto
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"crypto/sha3"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF 从密码派生 key. 不同的 KDF 参数不同, Params 返回可以 JSON 编码的参数, 和 ID 一起保存,
+// 解密时使用 DecodeKDF 从 ID 和参数重新得到 KDF.
+//
+// 参数来自不可信的输入时, Derive 在计算之前检查参数的范围, 超出范围返回 ErrKDFPolicy.
+// 内置的 KDF 使用 Policy 字段检查, nil 表示 DefaultKDFPolicy().
+type KDF interface {
+	ID() string
+	Derive(password, salt []byte) ([]byte, error)
+	Params() any
+}
+
+const (
+	KDFScrypt        = "scrypt"
+	KDFPBKDF2SHA256  = "pbkdf2-sha256"
+	KDFPBKDF2SHA3512 = "pbkdf2-sha3-512"
+)
+
+// Argon2idKDF 是 Argon2id 的 KDF, 推荐使用.
+type Argon2idKDF struct {
+	Argon2Params
+	Policy *KDFPolicy `json:"-"`
+}
+
+func (k *Argon2idKDF) ID() string  { return KDFArgon2id }
+func (k *Argon2idKDF) Params() any { return k.Argon2Params }
+
+func (k *Argon2idKDF) Derive(password, salt []byte) ([]byte, error) {
+	if err := k.Policy.orDefault().check(argon2.Version, &k.Argon2Params, len(salt)); err != nil {
+		return nil, err
+	}
+	key, _, err := Argon2id(password, &k.Argon2Params, salt)
+	return key, err
+}
+
+// ScryptKDF 是 scrypt 的 KDF, N 必须是 2 的幂, 使用 128*N*R bytes memory.
+// OWASP 推荐的最低参数: N=2^17, R=8, P=1.
+type ScryptKDF struct {
+	N      int        `json:"n"`
+	R      int        `json:"r"`
+	P      int        `json:"p"`
+	KeyLen int        `json:"key_len"`
+	Policy *KDFPolicy `json:"-"`
+}
+
+func (k *ScryptKDF) ID() string  { return KDFScrypt }
+func (k *ScryptKDF) Params() any { return *k }
+
+func (k *ScryptKDF) Derive(password, salt []byte) ([]byte, error) {
+	if err := k.check(len(salt)); err != nil {
+		return nil, err
+	}
+	return scrypt.Key(password, salt, k.N, k.R, k.P, k.KeyLen)
+}
+
+// check uses the memory, work, key and salt limits of k.Policy.
+func (k *ScryptKDF) check(saltLen int) error {
+	p := k.Policy.orDefault()
+	if k.N <= 1 || k.N > 1<<40 || k.N&(k.N-1) != 0 {
+		return fmt.Errorf("%w: scrypt N %d is not a power of 2 in [2, 2^40]", ErrKDFPolicy, k.N)
+	}
+	// r and p are at most 2^10, N*r*p does not overflow.
+	if k.R < 1 || k.P < 1 || k.R > 1<<10 || k.P > 1<<10 {
+		return fmt.Errorf("%w: scrypt r %d, p %d", ErrKDFPolicy, k.R, k.P)
+	}
+	memoryKB := uint64(k.N) * uint64(k.R) / 8 // 128*N*R bytes
+	if err := checkRange("memory", memoryKB, 0, uint64(p.MaxMemory)); err != nil {
+		return err
+	}
+	if err := checkRange("scrypt N*r*p", uint64(k.N)*uint64(k.R)*uint64(k.P), 0, p.MaxScryptWork); err != nil {
+		return err
+	}
+	if err := checkRange("key_len", uint64(max(k.KeyLen, 0)), uint64(p.MinKeyLen), uint64(p.MaxKeyLen)); err != nil {
+		return err
+	}
+	return checkRange("salt length", uint64(saltLen), uint64(p.MinSaltLen), uint64(p.MaxSaltLen))
+}
+
+// PBKDF2KDF 是 PBKDF2 的 KDF, Hash 为 "sha256" 或者 "sha3-512", 由 ID 决定, 不保存在参数中.
+// OWASP 推荐的最低 iterations: PBKDF2-HMAC-SHA256 600000.
+type PBKDF2KDF struct {
+	Hash       string     `json:"-"`
+	Iterations int        `json:"iterations"`
+	KeyLen     int        `json:"key_len"`
+	Policy     *KDFPolicy `json:"-"`
+}
+
+// pbkdf2Hash is a hash supported by PBKDF2KDF, crypto/pbkdf2.Key 是 generic 的, 所以每个 hash 一个函数.
+type pbkdf2Hash struct {
+	key  func(password string, salt []byte, iter, keyLen int) ([]byte, error)
+	cost uint64 // 每次 iteration 的时间, 相对于 sha256, 用于 KDFPolicy.MaxPBKDF2Iterations
+}
+
+var pbkdf2Hashes = map[string]pbkdf2Hash{
+	"sha256": {
+		key: func(password string, salt []byte, iter, keyLen int) ([]byte, error) {
+			return pbkdf2.Key(sha256.New, password, salt, iter, keyLen)
+		},
+		cost: 1,
+	},
+	"sha3-512": {
+		key: func(password string, salt []byte, iter, keyLen int) ([]byte, error) {
+			return pbkdf2.Key(sha3.New512, password, salt, iter, keyLen)
+		},
+		cost: 5,
+	},
+}
+
+// NewPBKDF2SHA256 returns a PBKDF2-HMAC-SHA256 KDF.
+func NewPBKDF2SHA256(iterations, keyLen int) *PBKDF2KDF {
+	return &PBKDF2KDF{Hash: "sha256", Iterations: iterations, KeyLen: keyLen}
+}
+
+// NewPBKDF2SHA3512 returns a PBKDF2-HMAC-SHA3-512 KDF, sha3-512 比 sha256 慢 5~6 倍.
+func NewPBKDF2SHA3512(iterations, keyLen int) *PBKDF2KDF {
+	return &PBKDF2KDF{Hash: "sha3-512", Iterations: iterations, KeyLen: keyLen}
+}
+
+func (k *PBKDF2KDF) ID() string  { return "pbkdf2-" + k.Hash }
+func (k *PBKDF2KDF) Params() any { return *k }
+
+func (k *PBKDF2KDF) Derive(password, salt []byte) ([]byte, error) {
+	h, ok := pbkdf2Hashes[k.Hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: pbkdf2 hash %q", ErrUnsupportedKDF, k.Hash)
+	}
+	p := k.Policy.orDefault()
+	maxIterations := p.MaxPBKDF2Iterations / h.cost
+	if p.MaxPBKDF2Iterations != 0 {
+		maxIterations = max(maxIterations, 1) // 0 means no limit
+	}
+	if err := checkRange("iterations", uint64(max(k.Iterations, 0)), 1, maxIterations); err != nil {
+		return nil, err
+	}
+	if err := checkRange("key_len", uint64(max(k.KeyLen, 0)), uint64(p.MinKeyLen), uint64(p.MaxKeyLen)); err != nil {
+		return nil, err
+	}
+	if err := checkRange("salt length", uint64(len(salt)), uint64(p.MinSaltLen), uint64(p.MaxSaltLen)); err != nil {
+		return nil, err
+	}
+	return h.key(string(password), salt, k.Iterations, k.KeyLen)
+}
+
+// kdfKeyLen returns the key length of the built-in KDFs without deriving, ok is false for other KDFs.
+func kdfKeyLen(k KDF) (n int, ok bool) {
+	switch k := k.(type) {
+	case *Argon2idKDF:
+		return int(k.KeyLen), true
+	case *ScryptKDF:
+		return k.KeyLen, true
+	case *PBKDF2KDF:
+		return k.KeyLen, true
+	}
+	return 0, false
+}
+
+// setKDFPolicy sets the policy of the built-in KDFs, other KDFs check their parameters by themselves.
+func setKDFPolicy(k KDF, p *KDFPolicy) {
+	switch k := k.(type) {
+	case *Argon2idKDF:
+		k.Policy = p
+	case *ScryptKDF:
+		k.Policy = p
+	case *PBKDF2KDF:
+		k.Policy = p
+	}
+}
+
+// KDFDecoder 从 JSON 参数创建 KDF.
+type KDFDecoder func(params []byte) (KDF, error)
+
+var (
+	kdfMu       sync.RWMutex
+	kdfRegistry = map[string]KDFDecoder{}
+)
+
+func init() {
+	RegisterKDF(KDFArgon2id, func(params []byte) (KDF, error) {
+		k := &Argon2idKDF{}
+		return k, json.Unmarshal(params, &k.Argon2Params)
+	})
+	RegisterKDF(KDFScrypt, func(params []byte) (KDF, error) {
+		k := &ScryptKDF{}
+		return k, json.Unmarshal(params, k)
+	})
+	for hash := range pbkdf2Hashes {
+		RegisterKDF("pbkdf2-"+hash, func(params []byte) (KDF, error) {
+			k := &PBKDF2KDF{Hash: hash}
+			return k, json.Unmarshal(params, k)
+		})
+	}
+}
+
+// RegisterKDF 注册 id 的 decoder, 通常在 init 中调用. id 重复或者 decode 为 nil 会 panic.
+func RegisterKDF(id string, decode KDFDecoder) {
+	kdfMu.Lock()
+	defer kdfMu.Unlock()
+	if decode == nil {
+		panic("crypto: RegisterKDF decoder is nil")
+	}
+	if _, dup := kdfRegistry[id]; dup {
+		panic("crypto: RegisterKDF called twice for " + id)
+	}
+	kdfRegistry[id] = decode
+}
+
+// DecodeKDF 使用注册的 decoder 创建 KDF, 未注册的 id 返回 ErrUnsupportedKDF, 参数错误返回 ErrMalformedEnvelope.
+func DecodeKDF(id string, params []byte) (KDF, error) {
+	kdfMu.RLock()
+	decode, ok := kdfRegistry[id]
+	kdfMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKDF, id)
+	}
+	k, err := decode(params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s parameters: %w", ErrMalformedEnvelope, id, err)
+	}
+	return k, nil
+}
+
+// KDFs returns the registered ids in order.
+func KDFs() []string {
+	kdfMu.RLock()
+	defer kdfMu.RUnlock()
+	ids := make([]string, 0, len(kdfRegistry))
+	for id := range kdfRegistry {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
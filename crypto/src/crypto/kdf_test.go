@@ -0,0 +1,273 @@
+package crypto_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"local/src/crypto"
+)
+
+// 测试用的 KDF, 减少计算量
+var testKDFs = []crypto.KDF{
+	&crypto.Argon2idKDF{Argon2Params: crypto.Argon2Params{Memory: 64, Time: 1, Threads: 1, KeyLen: 32}},
+	&crypto.ScryptKDF{N: 1024, R: 8, P: 1, KeyLen: 32},
+	crypto.NewPBKDF2SHA256(1000, 32),
+	crypto.NewPBKDF2SHA3512(1000, 32),
+}
+
+func TestKDFKnownAnswer(t *testing.T) {
+	// RFC 7914 的 salt 只有 4 bytes
+	policy := crypto.DefaultKDFPolicy()
+	policy.MinSaltLen = 0
+
+	tests := []struct {
+		name           string
+		kdf            crypto.KDF
+		password, salt string
+		want           string
+	}{
+		{
+			"scrypt, RFC 7914",
+			&crypto.ScryptKDF{N: 1024, R: 8, P: 16, KeyLen: 64, Policy: policy},
+			"password", "NaCl",
+			"fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640",
+		},
+		{
+			"pbkdf2-sha256, RFC 7914",
+			&crypto.PBKDF2KDF{Hash: "sha256", Iterations: 1, KeyLen: 64, Policy: policy},
+			"passwd", "salt",
+			"55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783",
+		},
+		{
+			"argon2id",
+			&crypto.Argon2idKDF{Argon2Params: crypto.Argon2Params{Memory: 65536, Time: 2, Threads: 4, KeyLen: 32}},
+			"password", "somesalt",
+			"1a9677b0afe81fda7b548895e7a1bfeb8668ffc19a530e37e088a668fab1c02a",
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.kdf.Derive([]byte(tt.password), []byte(tt.salt))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("%s: Derive() = %x, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSealWithKDF(t *testing.T) {
+	password := []byte("password")
+	plaintext := []byte("this is a KDF envelope test!!!")
+
+	for _, kdf := range testKDFs {
+		env, err := crypto.SealWithKDF(kdf, password, plaintext)
+		if err != nil {
+			t.Fatalf("%s: %v", kdf.ID(), err)
+		}
+
+		// 从 JSON 解码, registry 根据 ID 得到 KDF
+		je, err := json.Marshal(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(string(je))
+		var decoded crypto.Envelope
+		if err := json.Unmarshal(je, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		got, err := crypto.OpenWithPassword(password, &decoded)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("%s: OpenWithPassword() = %q, %v", kdf.ID(), got, err)
+		}
+
+		// 参数 JSON 的格式不影响认证
+		indented := decoded
+		var buf bytes.Buffer
+		json.Indent(&buf, decoded.KDFParams, "", "  ")
+		indented.KDFParams = buf.Bytes()
+		if _, err := crypto.OpenWithPassword(password, &indented); err != nil {
+			t.Errorf("%s: indented params: %v", kdf.ID(), err)
+		}
+
+		if _, err := crypto.OpenWithPassword([]byte("wrong"), &decoded); !errors.Is(err, crypto.ErrAuthFailed) {
+			t.Errorf("%s: wrong password: err = %v, want ErrAuthFailed", kdf.ID(), err)
+		}
+
+		// binary
+		bin, err := env.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fromBinary crypto.Envelope
+		if err := fromBinary.UnmarshalBinary(bin); err != nil {
+			t.Fatalf("%s: UnmarshalBinary() = %v", kdf.ID(), err)
+		}
+		if got, err := crypto.OpenWithPassword(password, &fromBinary); err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("%s: OpenWithPassword(binary) = %q, %v", kdf.ID(), got, err)
+		}
+		for n := range len(bin) - len(plaintext) - 16 {
+			if err := fromBinary.UnmarshalBinary(bin[:n]); !errors.Is(err, crypto.ErrMalformedEnvelope) {
+				t.Errorf("%s: truncated %d bytes: err = %v, want ErrMalformedEnvelope", kdf.ID(), n, err)
+			}
+		}
+	}
+}
+
+func TestOpenKDFEnvelopeInvalid(t *testing.T) {
+	password := []byte("password")
+	env, err := crypto.SealWithKDF(crypto.NewPBKDF2SHA256(1000, 32), password, []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := crypto.SealWithKDF(crypto.NewPBKDF2SHA256(1000, 16), password, []byte("secret")); !errors.Is(err, crypto.ErrUnsupportedCipher) {
+		t.Errorf("SealWithKDF(key_len 16) err = %v, want ErrUnsupportedCipher", err)
+	}
+
+	// 调用者的 policy
+	policy := crypto.DefaultKDFPolicy()
+	policy.MaxPBKDF2Iterations = 500
+	if _, err := policy.OpenWithPassword(password, env); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("policy.OpenWithPassword() err = %v, want ErrKDFPolicy", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(e *crypto.Envelope)
+		want   error
+	}{
+		{"params", func(e *crypto.Envelope) { e.KDFParams = []byte(`{"iterations":1001,"key_len":32}`) }, crypto.ErrAuthFailed},
+		{"kdf", func(e *crypto.Envelope) { e.KDF = crypto.KDFPBKDF2SHA3512 }, crypto.ErrAuthFailed},
+		{"salt", func(e *crypto.Envelope) { e.SaltHex = "00" + e.SaltHex[2:] }, crypto.ErrAuthFailed},
+		{"unknown kdf", func(e *crypto.Envelope) { e.KDF = "bcrypt" }, crypto.ErrUnsupportedKDF},
+		{"bad params", func(e *crypto.Envelope) { e.KDFParams = []byte(`{"iterations":"x"}`) }, crypto.ErrMalformedEnvelope},
+		{"version", func(e *crypto.Envelope) { e.Version = 3 }, crypto.ErrUnsupportedVersion},
+		{"version 1", func(e *crypto.Envelope) { e.Version = crypto.EnvelopeVersion }, crypto.ErrMalformedEnvelope},
+		{"argon2id", func(e *crypto.Envelope) { e.Argon2id = &crypto.Argon2Env{} }, crypto.ErrMalformedEnvelope},
+		{"cipher", func(e *crypto.Envelope) { e.Cipher = "aes-256-cbc" }, crypto.ErrUnsupportedCipher},
+		{"key_len", func(e *crypto.Envelope) { e.KDFParams = []byte(`{"iterations":1000,"key_len":16}`) }, crypto.ErrMalformedEnvelope},
+		// key_len 在 Derive 之前检查
+		{"key_len before derive", func(e *crypto.Envelope) {
+			e.KDF = crypto.KDFScrypt
+			e.KDFParams = []byte(`{"n":1048576,"r":8,"p":1,"key_len":16}`)
+		}, crypto.ErrMalformedEnvelope},
+		// 不可信的参数在计算之前被拒绝
+		{"iterations", func(e *crypto.Envelope) { e.KDFParams = []byte(`{"iterations":1000000000,"key_len":32}`) }, crypto.ErrKDFPolicy},
+		{"scrypt memory", func(e *crypto.Envelope) {
+			e.KDF = crypto.KDFScrypt
+			e.KDFParams = []byte(`{"n":1073741824,"r":8,"p":1,"key_len":32}`)
+		}, crypto.ErrKDFPolicy},
+		{"scrypt p", func(e *crypto.Envelope) {
+			e.KDF = crypto.KDFScrypt
+			e.KDFParams = []byte(`{"n":1048576,"r":8,"p":1024,"key_len":32}`)
+		}, crypto.ErrKDFPolicy},
+		{"scrypt n overflow", func(e *crypto.Envelope) {
+			e.KDF = crypto.KDFScrypt
+			e.KDFParams = []byte(`{"n":4611686018427387904,"r":1024,"p":1,"key_len":32}`)
+		}, crypto.ErrKDFPolicy},
+		{"sha3-512 iterations", func(e *crypto.Envelope) {
+			e.KDF = crypto.KDFPBKDF2SHA3512
+			e.KDFParams = []byte(`{"iterations":3000000,"key_len":32}`)
+		}, crypto.ErrKDFPolicy},
+		{"scrypt n", func(e *crypto.Envelope) {
+			e.KDF = crypto.KDFScrypt
+			e.KDFParams = []byte(`{"n":1000,"r":8,"p":1,"key_len":32}`)
+		}, crypto.ErrKDFPolicy},
+		{"argon2id memory", func(e *crypto.Envelope) {
+			e.KDF = crypto.KDFArgon2id
+			e.KDFParams = []byte(`{"memory":4194304,"time":1,"threads":1,"key_len":32}`)
+		}, crypto.ErrKDFPolicy},
+	}
+	for _, tt := range tests {
+		e := *env
+		tt.modify(&e)
+		if _, err := crypto.OpenWithPassword(password, &e); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestKDFRegistry(t *testing.T) {
+	want := []string{crypto.KDFArgon2id, crypto.KDFPBKDF2SHA256, crypto.KDFPBKDF2SHA3512, crypto.KDFScrypt}
+	got := crypto.KDFs()
+	if len(got) != len(want) {
+		t.Fatalf("KDFs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("KDFs() = %v, want %v", got, want)
+		}
+	}
+
+	for _, kdf := range testKDFs {
+		params, _ := json.Marshal(kdf.Params())
+		decoded, err := crypto.DecodeKDF(kdf.ID(), params)
+		if err != nil || decoded.ID() != kdf.ID() {
+			t.Errorf("DecodeKDF(%s, %s) = %v, %v", kdf.ID(), params, decoded, err)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterKDF() twice did not panic")
+		}
+	}()
+	crypto.RegisterKDF(crypto.KDFScrypt, func(params []byte) (crypto.KDF, error) { return nil, nil })
+}
+
+func TestRewrap(t *testing.T) {
+	password := []byte("password")
+	plaintext := []byte("secret")
+	pbkdf2 := crypto.NewPBKDF2SHA256(1000, 32)
+	scrypt := &crypto.ScryptKDF{N: 1024, R: 8, P: 1, KeyLen: 32}
+
+	// SealWithPassword 生成的旧 Envelope
+	legacy, err := crypto.SealWithPassword(password, plaintext, fastParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := crypto.Rewrap(password, legacy, pbkdf2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := crypto.OpenWithPassword(password, env); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("OpenWithPassword(Rewrap()) = %q, %v", got, err)
+	}
+	if _, err := crypto.Rewrap([]byte("wrong"), legacy, pbkdf2); !errors.Is(err, crypto.ErrAuthFailed) {
+		t.Errorf("Rewrap(wrong password) err = %v, want ErrAuthFailed", err)
+	}
+
+	tests := []struct {
+		current crypto.KDF
+		want    bool
+	}{
+		{crypto.NewPBKDF2SHA256(1000, 32), false},
+		{crypto.NewPBKDF2SHA256(2000, 32), true},
+		{crypto.NewPBKDF2SHA3512(1000, 32), true},
+		{scrypt, true},
+	}
+	for _, tt := range tests {
+		if got, err := crypto.NeedsMigration(env, tt.current); got != tt.want || err != nil {
+			t.Errorf("NeedsMigration(%s %+v) = %v, %v, want %v", tt.current.ID(), tt.current.Params(), got, err, tt.want)
+		}
+	}
+
+	// pbkdf2 -> scrypt
+	env2, err := crypto.Rewrap(password, env, scrypt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := crypto.OpenWithPassword(password, env2); err != nil || !bytes.Equal(got, plaintext) {
+		t.Errorf("OpenWithPassword(Rewrap()) = %q, %v", got, err)
+	}
+	if got, _ := crypto.NeedsMigration(env2, scrypt); got {
+		t.Error("NeedsMigration() after Rewrap() = true")
+	}
+	if _, err := crypto.Rewrap([]byte("wrong"), env, scrypt); !errors.Is(err, crypto.ErrAuthFailed) {
+		t.Errorf("Rewrap(wrong password) err = %v, want ErrAuthFailed", err)
+	}
+}
@@ -0,0 +1,133 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// KDFPolicy 限制解密时可以接受的 KDF 参数, 加密时使用同一个 policy 检查, 保证生成的数据可以被解密.
+// Argon2Env 和 PHC string 中的参数来自不可信的输入, 攻击者可以构造 memory 为几个 GB 的参数耗尽资源,
+// 也可以降低参数 (downgrade). 所以在调用 Argon2id 之前必须先检查参数.
+//
+// Max 为 0 表示没有上限, Versions 为空表示接受所有 argon2 支持的 version.
+// Memory, KeyLen 和 SaltLen 的范围也用于 scrypt 和 PBKDF2.
+type KDFPolicy struct {
+	MinMemory, MaxMemory   uint32 // KB
+	MinTime, MaxTime       uint32
+	MinThreads, MaxThreads uint8
+	MinKeyLen, MaxKeyLen   uint32
+	Versions               []int
+	MinSaltLen, MaxSaltLen int
+
+	// MaxArgon2Work 限制 Argon2id 的 memory*time (KB), 时间和 memory*time 成正比, 单独的 MaxMemory 和 MaxTime
+	// 不能限制两者同时取最大值.
+	MaxArgon2Work uint64
+	// MaxScryptWork 限制 scrypt 的 N*r*p, 时间和 N*r*p 成正比, memory 只和 N*r 有关, 所以 MaxMemory 不能限制 p.
+	MaxScryptWork uint64
+	// MaxPBKDF2Iterations 限制 PBKDF2 的 iterations, 以 PBKDF2-HMAC-SHA256 计算, 更慢的 hash 按相对时间换算.
+	MaxPBKDF2Iterations uint64
+}
+
+// defaultKDFPolicy is returned by DefaultKDFPolicy, it is never modified.
+// 每种 KDF 的上限的计算时间大致相同, 约 1~2 秒: Argon2id memory*time = 4 GB (例如 1 GB 4 pass, 4 threads),
+// scrypt N*r*p = 2^23 (1 GB, p=1), PBKDF2-HMAC-SHA256 10M iterations.
+var defaultKDFPolicy = KDFPolicy{
+	MinMemory:  8,
+	MaxMemory:  1024 * 1024, // 1 GB
+	MinTime:    1,
+	MaxTime:    64,
+	MinThreads: 1,
+	MaxThreads: 64,
+	MinKeyLen:  16,
+	MaxKeyLen:  64,
+	Versions:   []int{0x13},
+	MinSaltLen: 8,
+	MaxSaltLen: 64,
+
+	MaxArgon2Work:       4 * 1024 * 1024, // 4 GB * 1 pass
+	MaxScryptWork:       1 << 23,
+	MaxPBKDF2Iterations: 10_000_000,
+}
+
+// DefaultKDFPolicy 返回 OpenWithPassword, VerifyPassword 和 Policy 为 nil 的 KDF 使用的 policy,
+// 主要限制资源 (1 GB memory), 下限是 argon2 的最低要求. 需要防止 downgrade 时, 使用 OWASPArgon2Params 作为下限.
+//
+// 每次调用都返回新的 copy, 修改之后使用 (*KDFPolicy).SealWithPassword, (*KDFPolicy).OpenWithPassword 或者设置 KDF 的 Policy, 不影响其他调用.
+func DefaultKDFPolicy() *KDFPolicy {
+	p := defaultKDFPolicy
+	p.Versions = slices.Clone(p.Versions)
+	return &p
+}
+
+// orDefault returns p, or the default policy if p is nil.
+func (p *KDFPolicy) orDefault() *KDFPolicy {
+	if p == nil {
+		return &defaultKDFPolicy
+	}
+	return p
+}
+
+// Check 检查 env 中的参数, 返回的错误 wrap ErrKDFPolicy, 并说明超出了哪个范围.
+// nil policy 和 DefaultKDFPolicy() 相同, 其他方法也一样.
+func (p *KDFPolicy) Check(env *Argon2Env) error {
+	p = p.orDefault()
+	if env == nil {
+		return fmt.Errorf("%w: missing argon2id parameters", ErrKDFPolicy)
+	}
+	salt, err := hex.DecodeString(env.SaltHex)
+	if err != nil {
+		return fmt.Errorf("%w: salt %q", ErrKDFPolicy, env.SaltHex)
+	}
+	return p.check(env.Version, &env.Argon2Params, len(salt))
+}
+
+// Argon2id 检查 env 中的参数之后, 使用 env 中的参数和 salt 派生 key.
+func (p *KDFPolicy) Argon2id(password []byte, env *Argon2Env) ([]byte, error) {
+	if err := p.Check(env); err != nil {
+		return nil, err
+	}
+	salt, _ := hex.DecodeString(env.SaltHex) // checked by Check()
+	key, _, err := Argon2id(password, &env.Argon2Params, salt)
+	return key, err
+}
+
+func (p *KDFPolicy) check(version int, params *Argon2Params, saltLen int) error {
+	if len(p.Versions) > 0 && !slices.Contains(p.Versions, version) {
+		return fmt.Errorf("%w: argon2 version %d is not one of %v", ErrKDFPolicy, version, p.Versions)
+	}
+	if err := checkRange("memory", uint64(params.Memory), uint64(p.MinMemory), uint64(p.MaxMemory)); err != nil {
+		return err
+	}
+	if err := checkRange("time", uint64(params.Time), uint64(p.MinTime), uint64(p.MaxTime)); err != nil {
+		return err
+	}
+	if err := checkRange("threads", uint64(params.Threads), uint64(p.MinThreads), uint64(p.MaxThreads)); err != nil {
+		return err
+	}
+	if err := checkRange("key_len", uint64(params.KeyLen), uint64(p.MinKeyLen), uint64(p.MaxKeyLen)); err != nil {
+		return err
+	}
+	if err := checkRange("salt length", uint64(saltLen), uint64(p.MinSaltLen), uint64(p.MaxSaltLen)); err != nil {
+		return err
+	}
+	if err := checkRange("memory*time", uint64(params.Memory)*uint64(params.Time), 0, p.MaxArgon2Work); err != nil {
+		return err
+	}
+	// argon2.IDKey silently increases memory to 8*threads
+	if params.Memory < 8*uint32(params.Threads) {
+		return fmt.Errorf("%w: memory %d is below 8*threads (%d)", ErrKDFPolicy, params.Memory, 8*uint32(params.Threads))
+	}
+	return nil
+}
+
+// checkRange returns an error if v is not in [lo, hi], hi == 0 means no upper bound.
+func checkRange(name string, v, lo, hi uint64) error {
+	if v < lo {
+		return fmt.Errorf("%w: %s %d is below the minimum %d", ErrKDFPolicy, name, v, lo)
+	}
+	if hi != 0 && v > hi {
+		return fmt.Errorf("%w: %s %d is above the maximum %d", ErrKDFPolicy, name, v, hi)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package crypto_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"local/src/crypto"
+)
+
+func TestKDFPolicyCheck(t *testing.T) {
+	policy := crypto.KDFPolicy{
+		MinMemory: 19 * 1024, MaxMemory: 256 * 1024,
+		MinTime: 2, MaxTime: 10,
+		MinThreads: 1, MaxThreads: 4,
+		MinKeyLen: 32, MaxKeyLen: 32,
+		Versions:   []int{19},
+		MinSaltLen: 16, MaxSaltLen: 32,
+	}
+	valid := crypto.Argon2Env{
+		Version:      19,
+		Argon2Params: crypto.Argon2Params{Memory: 64 * 1024, Time: 3, Threads: 4, KeyLen: 32},
+		SaltHex:      strings.Repeat("ab", 16),
+	}
+	if err := policy.Check(&valid); err != nil {
+		t.Fatalf("Check(valid) = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(e *crypto.Argon2Env)
+		want   string // part of the error message
+	}{
+		{"version", func(e *crypto.Argon2Env) { e.Version = 16 }, "version 16"},
+		{"memory too large", func(e *crypto.Argon2Env) { e.Memory = 4 * 1024 * 1024 }, "memory 4194304 is above the maximum 262144"},
+		{"memory too small", func(e *crypto.Argon2Env) { e.Memory = 1024 }, "memory 1024 is below the minimum 19456"},
+		{"time too large", func(e *crypto.Argon2Env) { e.Time = 1000 }, "time 1000 is above"},
+		{"time too small", func(e *crypto.Argon2Env) { e.Time = 1 }, "time 1 is below"},
+		{"threads", func(e *crypto.Argon2Env) { e.Threads = 255 }, "threads 255 is above"},
+		{"key_len", func(e *crypto.Argon2Env) { e.KeyLen = 16 }, "key_len 16 is below"},
+		{"salt too short", func(e *crypto.Argon2Env) { e.SaltHex = "abcd" }, "salt length 2 is below"},
+		{"salt too long", func(e *crypto.Argon2Env) { e.SaltHex = strings.Repeat("ab", 64) }, "salt length 64 is above"},
+		{"salt hex", func(e *crypto.Argon2Env) { e.SaltHex = "xyz" }, "salt"},
+	}
+	for _, tt := range tests {
+		e := valid
+		tt.modify(&e)
+		err := policy.Check(&e)
+		if !errors.Is(err, crypto.ErrKDFPolicy) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: Check() = %v, want ErrKDFPolicy with %q", tt.name, err, tt.want)
+		}
+		if _, err := policy.Argon2id([]byte("password"), &e); !errors.Is(err, crypto.ErrKDFPolicy) {
+			t.Errorf("%s: Argon2id() = %v, want ErrKDFPolicy", tt.name, err)
+		}
+	}
+
+	if err := policy.Check(nil); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("Check(nil) = %v, want ErrKDFPolicy", err)
+	}
+	// 0 表示没有限制
+	var unlimited crypto.KDFPolicy
+	e := valid
+	e.Memory = 4 * 1024 * 1024
+	if err := unlimited.Check(&e); err != nil {
+		t.Errorf("unlimited policy: Check() = %v", err)
+	}
+}
+
+// 不可信的参数在 Argon2id 运行之前被拒绝
+func TestKDFPolicyUntrusted(t *testing.T) {
+	env, err := crypto.SealWithPassword([]byte("password"), []byte("secret"), fastParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Argon2id.Memory = 4 * 1024 * 1024 // 4 GB
+	if _, err := crypto.OpenWithPassword([]byte("password"), env); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("OpenWithPassword() = %v, want ErrKDFPolicy", err)
+	}
+	// memory 和 time 都不超过上限, 但是 memory*time 超过 MaxArgon2Work
+	env.Argon2id.Memory, env.Argon2id.Time = 1024*1024, 64 // 1 GB, 64 pass
+	if _, err := crypto.OpenWithPassword([]byte("password"), env); !errors.Is(err, crypto.ErrKDFPolicy) || !strings.Contains(err.Error(), "memory*time") {
+		t.Errorf("m=1GB, t=64: OpenWithPassword() = %v, want ErrKDFPolicy", err)
+	}
+
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=4194304,t=1,p=1$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG",
+		"$argon2id$v=19$m=65536,t=1000000,p=1$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG",
+		"$argon2id$v=19$m=65536,t=1,p=255$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG",
+		"$argon2id$v=19$m=1048576,t=64,p=4$c29tZXNhbHQ$RdescudvJCsgt3ub+b+dWRWJTmaaJObG",
+	} {
+		if _, err := crypto.VerifyPassword(encoded, []byte("password")); !errors.Is(err, crypto.ErrKDFPolicy) {
+			t.Errorf("VerifyPassword(%s) = %v, want ErrKDFPolicy", encoded, err)
+		}
+	}
+}
+
+// 加密和解密使用同一个 policy, 超出 policy 的参数在加密时就被拒绝
+func TestKDFPolicyOpen(t *testing.T) {
+	params := &crypto.Argon2Params{Memory: 64, Time: 100, Threads: 1} // time is above DefaultKDFPolicy().MaxTime
+	policy := crypto.DefaultKDFPolicy()
+	policy.MaxTime = 100
+
+	if _, err := crypto.SealWithPassword([]byte("password"), []byte("secret"), params); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("SealWithPassword() = %v, want ErrKDFPolicy", err)
+	}
+	env, err := policy.SealWithPassword([]byte("password"), []byte("secret"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crypto.OpenWithPassword([]byte("password"), env); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("OpenWithPassword() = %v, want ErrKDFPolicy", err)
+	}
+	if got, err := policy.OpenWithPassword([]byte("password"), env); err != nil || string(got) != "secret" {
+		t.Errorf("policy.OpenWithPassword() = %q, %v", got, err)
+	}
+
+	if _, err := crypto.HashPassword([]byte("password"), params); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("HashPassword() = %v, want ErrKDFPolicy", err)
+	}
+	encoded, err := policy.HashPassword([]byte("password"), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := crypto.VerifyPassword(encoded, []byte("password")); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("VerifyPassword() = %v, want ErrKDFPolicy", err)
+	}
+	if ok, err := policy.VerifyPassword(encoded, []byte("password")); !ok || err != nil {
+		t.Errorf("policy.VerifyPassword() = %t, %v", ok, err)
+	}
+
+	// nil policy is the default policy
+	var nilPolicy *crypto.KDFPolicy
+	if _, err := nilPolicy.OpenWithPassword([]byte("password"), env); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("nil policy: OpenWithPassword() = %v, want ErrKDFPolicy", err)
+	}
+	if _, err := nilPolicy.VerifyPassword(encoded, []byte("password")); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("nil policy: VerifyPassword() = %v, want ErrKDFPolicy", err)
+	}
+	if _, err := nilPolicy.Argon2id([]byte("password"), env.Argon2id); !errors.Is(err, crypto.ErrKDFPolicy) {
+		t.Errorf("nil policy: Argon2id() = %v, want ErrKDFPolicy", err)
+	}
+
+	// DefaultKDFPolicy() returns a copy
+	if crypto.DefaultKDFPolicy().MaxTime == 100 {
+		t.Error("modifying DefaultKDFPolicy() changed the default policy")
+	}
+}
+
+// 加密接受的参数, 默认的 policy 都可以解密
+func TestKDFPolicyBounds(t *testing.T) {
+	policy := crypto.DefaultKDFPolicy()
+	for _, params := range []crypto.Argon2Params{
+		{Memory: policy.MinMemory, Time: policy.MaxTime, Threads: 1, KeyLen: policy.MinKeyLen},
+		{Memory: policy.MinMemory, Time: policy.MinTime, Threads: 1, KeyLen: policy.MaxKeyLen},
+		{Memory: 8 * 64, Time: 1, Threads: policy.MaxThreads, KeyLen: 32},
+	} {
+		env, err := crypto.SealWithPassword([]byte("password"), []byte("secret"), &params)
+		if err != nil {
+			t.Fatalf("%+v: SealWithPassword() = %v", params, err)
+		}
+		if got, err := crypto.OpenWithPassword([]byte("password"), env); err != nil || string(got) != "secret" {
+			t.Errorf("%+v: OpenWithPassword() = %q, %v", params, got, err)
+		}
+
+		encoded, err := crypto.HashPassword([]byte("password"), &params)
+		if err != nil {
+			t.Fatalf("%+v: HashPassword() = %v", params, err)
+		}
+		if ok, err := crypto.VerifyPassword(encoded, []byte("password")); !ok || err != nil {
+			t.Errorf("%+v: VerifyPassword() = %t, %v", params, ok, err)
+		}
+	}
+
+	// 解密拒绝的参数, 加密也拒绝
+	for _, params := range []crypto.Argon2Params{
+		{Memory: 64, Time: 1, Threads: 1, KeyLen: policy.MinKeyLen - 8},
+		{Memory: 64, Time: 1, Threads: 1, KeyLen: policy.MaxKeyLen + 1},
+		{Memory: 64, Time: policy.MaxTime + 1, Threads: 1},
+		{Memory: policy.MaxMemory + 1, Time: 1, Threads: 1},
+	} {
+		if _, err := crypto.HashPassword([]byte("password"), &params); !errors.Is(err, crypto.ErrKDFPolicy) {
+			t.Errorf("%+v: HashPassword() = %v, want ErrKDFPolicy", params, err)
+		}
+		if params.KeyLen != 0 {
+			continue // SealWithPassword ignores KeyLen
+		}
+		if _, err := crypto.SealWithPassword([]byte("password"), []byte("secret"), &params); !errors.Is(err, crypto.ErrKDFPolicy) {
+			t.Errorf("%+v: SealWithPassword() = %v, want ErrKDFPolicy", params, err)
+		}
+	}
+}
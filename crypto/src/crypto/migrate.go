@@ -0,0 +1,41 @@
+package crypto
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// NeedsMigration 返回 env 是否使用了和 current 不同的 KDF 或者参数, version 1 的 env 使用 Argon2idKDF 比较.
+func NeedsMigration(env *Envelope, current KDF) (bool, error) {
+	kdf, _, _, err := env.decode()
+	if err != nil {
+		return false, err
+	}
+	if kdf == nil {
+		kdf = &Argon2idKDF{Argon2Params: env.Argon2id.Argon2Params}
+	}
+	if kdf.ID() != current.ID() {
+		return true, nil
+	}
+	a, err := json.Marshal(kdf.Params())
+	if err != nil {
+		return false, err
+	}
+	b, err := json.Marshal(current.Params())
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(a, b), nil
+}
+
+// Rewrap 使用 password 解密 env, 再用 to 重新加密为 version 2 的 Envelope, 用于把数据迁移到新的 KDF 或者更强的参数.
+// env 可以是 SealWithPassword 生成的 version 1. 一般在用户登录 (知道 password) 时调用, NeedsMigration 返回 true 时才需要 Rewrap.
+// 解密使用 DefaultKDFPolicy(), 需要其他 policy 时使用 (*KDFPolicy).OpenWithPassword 和 SealWithKDF.
+func Rewrap(password []byte, env *Envelope, to KDF) (*Envelope, error) {
+	plaintext, err := OpenWithPassword(password, env)
+	if err != nil {
+		return nil, err
+	}
+	defer clear(plaintext)
+	return SealWithKDF(to, password, plaintext)
+}
@@ -0,0 +1,174 @@
+package crypto
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// 保存登录密码使用 PHC string format (和 argon2 reference implementation, libsodium, passlib 兼容):
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>
+//
+// salt 和 hash 使用没有 padding 的标准 base64 编码. 参数和 salt 都在字符串中, 所以只需要保存这一个字符串.
+// Argon2id() 用于派生加密 key, HashPassword 用于保存和验证密码.
+const phcPrefix = "$" + KDFArgon2id + "$"
+
+// phcHash is a decoded PHC string.
+type phcHash struct {
+	version int
+	params  Argon2Params // KeyLen is len(hash)
+	salt    []byte
+	hash    []byte
+}
+
+// HashPassword 使用 Argon2id 和随机 salt 计算 password 的 hash, 返回 PHC string.
+// params 为 nil 时使用 DefaultArgon2Params, params.KeyLen 为 0 时使用 32.
+// params 超出 DefaultKDFPolicy() 返回 ErrKDFPolicy, 否则 VerifyPassword 无法验证.
+func HashPassword(password []byte, params *Argon2Params) (string, error) {
+	return defaultKDFPolicy.HashPassword(password, params)
+}
+
+// HashPassword 和 HashPassword 函数相同, 但是使用 p 检查 params, 验证时使用 p.VerifyPassword.
+func (p *KDFPolicy) HashPassword(password []byte, params *Argon2Params) (string, error) {
+	p = p.orDefault()
+	hp := hashParams(params)
+	if err := checkArgon2Params(&hp); err != nil {
+		return "", err
+	}
+	if err := p.check(argon2.Version, &hp, saltLen); err != nil {
+		return "", err
+	}
+
+	salt, err := RandomBytes(saltLen)
+	if err != nil {
+		return "", err
+	}
+	key, _, err := Argon2id(password, &hp, salt)
+	if err != nil {
+		return "", err
+	}
+	h := phcHash{version: argon2.Version, params: hp, salt: salt, hash: key}
+	return h.String(), nil
+}
+
+// VerifyPassword 使用 encoded 中的参数计算 password 的 hash, 并以 constant time 比较.
+// 密码错误返回 false, nil, encoded 格式错误返回 ErrInvalidHash, 参数超出 DefaultKDFPolicy() 返回 ErrKDFPolicy.
+func VerifyPassword(encoded string, password []byte) (bool, error) {
+	return defaultKDFPolicy.VerifyPassword(encoded, password)
+}
+
+// VerifyPassword 和 VerifyPassword 函数相同, 但是使用 p 检查 encoded 中的参数.
+func (p *KDFPolicy) VerifyPassword(encoded string, password []byte) (bool, error) {
+	p = p.orDefault()
+	h, err := parsePHC(encoded)
+	if err != nil {
+		return false, err
+	}
+	if err := p.check(h.version, &h.params, len(h.salt)); err != nil {
+		return false, err
+	}
+	key := argon2.IDKey(password, h.salt, h.params.Time, h.params.Memory, h.params.Threads, h.params.KeyLen)
+	return subtle.ConstantTimeCompare(key, h.hash) == 1, nil
+}
+
+// NeedsRehash 返回 encoded 的参数是否和 params 不同 (包括 hash 长度和 salt 长度),
+// 登录时 VerifyPassword 成功之后, 如果 NeedsRehash 返回 true, 应该使用 HashPassword 重新计算并保存.
+// params 为 nil 时使用 DefaultArgon2Params.
+func NeedsRehash(encoded string, params *Argon2Params) (bool, error) {
+	h, err := parsePHC(encoded)
+	if err != nil {
+		return false, err
+	}
+	return h.params != hashParams(params) || len(h.salt) != saltLen, nil
+}
+
+// hashParams returns the parameters used by HashPassword.
+func hashParams(params *Argon2Params) Argon2Params {
+	p := DefaultArgon2Params
+	if params != nil {
+		p = *params
+	}
+	if p.KeyLen == 0 {
+		p.KeyLen = 32
+	}
+	return p
+}
+
+// checkArgon2Params checks the limits of the argon2 spec, argon2.IDKey silently adjusts memory below 8*threads.
+func checkArgon2Params(p *Argon2Params) error {
+	if p.Time < 1 || p.Threads < 1 || p.Memory < 8*uint32(p.Threads) || p.KeyLen < 4 {
+		return fmt.Errorf("invalid argon2id parameters: m=%d, t=%d, p=%d, key_len=%d", p.Memory, p.Time, p.Threads, p.KeyLen)
+	}
+	return nil
+}
+
+// String returns the PHC string.
+func (h *phcHash) String() string {
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", phcPrefix, h.version,
+		h.params.Memory, h.params.Time, h.params.Threads,
+		base64.RawStdEncoding.EncodeToString(h.salt), base64.RawStdEncoding.EncodeToString(h.hash))
+}
+
+// parsePHC decodes $argon2id$v=19$m=65536,t=3,p=4$salt$hash, the parameters must be in this order.
+func parsePHC(encoded string) (*phcHash, error) {
+	invalid := func(format string, a ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidHash, fmt.Sprintf(format, a...))
+	}
+
+	rest, ok := strings.CutPrefix(encoded, phcPrefix)
+	if !ok {
+		return nil, invalid("not an argon2id hash")
+	}
+	fields := strings.Split(rest, "$")
+	if len(fields) != 4 {
+		return nil, invalid("%d fields", len(fields)+1)
+	}
+
+	var h phcHash
+	v, ok := strings.CutPrefix(fields[0], "v=")
+	version, err := strconv.ParseUint(v, 10, 8)
+	if !ok || err != nil {
+		return nil, invalid("version %q", fields[0])
+	}
+	h.version = int(version)
+	if h.version != argon2.Version {
+		return nil, fmt.Errorf("%w: argon2 version %d", ErrUnsupportedVersion, h.version)
+	}
+
+	params := strings.Split(fields[1], ",")
+	names := []string{"m", "t", "p"}
+	values := make([]uint64, len(names))
+	if len(params) != len(names) {
+		return nil, invalid("parameters %q", fields[1])
+	}
+	for i, name := range names {
+		v, ok := strings.CutPrefix(params[i], name+"=")
+		bits := 32
+		if name == "p" {
+			bits = 8
+		}
+		n, err := strconv.ParseUint(v, 10, bits)
+		if !ok || err != nil || (len(v) > 1 && v[0] == '0') {
+			return nil, invalid("parameter %q", params[i])
+		}
+		values[i] = n
+	}
+	h.params.Memory, h.params.Time, h.params.Threads = uint32(values[0]), uint32(values[1]), uint8(values[2])
+
+	if h.salt, err = base64.RawStdEncoding.Strict().DecodeString(fields[2]); err != nil || len(h.salt) < 8 {
+		return nil, invalid("salt %q", fields[2])
+	}
+	if h.hash, err = base64.RawStdEncoding.Strict().DecodeString(fields[3]); err != nil {
+		return nil, invalid("hash %q", fields[3])
+	}
+	h.params.KeyLen = uint32(len(h.hash))
+	if err := checkArgon2Params(&h.params); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	return &h, nil
+}
@@ -0,0 +1,116 @@
+package crypto_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"local/src/crypto"
+)
+
+func TestHashPassword(t *testing.T) {
+	password := []byte("password")
+	encoded, err := crypto.HashPassword(password, fastParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(encoded)
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("HashPassword() = %s", encoded)
+	}
+
+	if ok, err := crypto.VerifyPassword(encoded, password); !ok || err != nil {
+		t.Errorf("VerifyPassword() = %v, %v, want true", ok, err)
+	}
+	if ok, err := crypto.VerifyPassword(encoded, []byte("wrong")); ok || err != nil {
+		t.Errorf("VerifyPassword(wrong) = %v, %v, want false", ok, err)
+	}
+
+	// 相同的密码每次 salt 不同
+	encoded2, _ := crypto.HashPassword(password, fastParams)
+	if encoded == encoded2 {
+		t.Error("HashPassword() reused the salt")
+	}
+}
+
+// 和其他实现生成的 hash 兼容, hash 由 argon2 reference implementation 生成:
+// echo -n "password" | argon2 somesalt -id -t 2 -m 16 -p 4 (-p 1)
+func TestVerifyPasswordInterop(t *testing.T) {
+	for _, encoded := range []string{
+		"$argon2id$v=19$m=65536,t=2,p=4$c29tZXNhbHQ$GpZ3sK/oH9p7VIiV56G/64Zo/8GaUw434IimaPqxwCo",
+		"$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc",
+	} {
+		if ok, err := crypto.VerifyPassword(encoded, []byte("password")); !ok || err != nil {
+			t.Errorf("VerifyPassword(%s) = %v, %v, want true", encoded, ok, err)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	encoded, err := crypto.HashPassword([]byte("password"), fastParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		params crypto.Argon2Params
+		want   bool
+	}{
+		{"same", *fastParams, false},
+		{"same key_len", crypto.Argon2Params{Memory: 64, Time: 1, Threads: 1, KeyLen: 32}, false},
+		{"memory", crypto.Argon2Params{Memory: 128, Time: 1, Threads: 1}, true},
+		{"time", crypto.Argon2Params{Memory: 64, Time: 2, Threads: 1}, true},
+		{"threads", crypto.Argon2Params{Memory: 64, Time: 1, Threads: 2}, true},
+		{"key_len", crypto.Argon2Params{Memory: 64, Time: 1, Threads: 1, KeyLen: 16}, true},
+	}
+	for _, tt := range tests {
+		if got, err := crypto.NeedsRehash(encoded, &tt.params); got != tt.want || err != nil {
+			t.Errorf("%s: NeedsRehash() = %v, %v, want %v", tt.name, got, err, tt.want)
+		}
+	}
+	if got, _ := crypto.NeedsRehash(encoded, nil); !got {
+		t.Error("NeedsRehash(default params) = false, want true")
+	}
+}
+
+func TestVerifyPasswordInvalid(t *testing.T) {
+	const (
+		salt = "c29tZXNhbHQ"
+		hash = "RdescudvJCsgt3ub+b+dWRWJTmaaJObG"
+	)
+	tests := []struct {
+		encoded string
+		want    error
+	}{
+		{"", crypto.ErrInvalidHash},
+		{"$argon2i$v=19$m=64,t=1,p=1$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=64,t=1,p=1$" + salt, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + hash + "$", crypto.ErrInvalidHash},
+		{"$argon2id$v=16$m=64,t=1,p=1$" + salt + "$" + hash, crypto.ErrUnsupportedVersion},
+		{"$argon2id$m=64,t=1,p=1$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$t=1,m=64,p=1$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=64,t=1$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=064,t=1,p=1$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=-1,t=1,p=1$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=64,t=1,p=256$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=4,t=1,p=1$" + salt + "$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=64,t=1,p=1$" + salt + "=$" + hash, crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$" + hash, crypto.ErrInvalidHash}, // salt < 8 bytes
+		{"$argon2id$v=19$m=64,t=1,p=1$" + salt + "$", crypto.ErrInvalidHash},
+		{"$argon2id$v=19$m=64,t=1,p=1$" + salt + "$!!!", crypto.ErrInvalidHash},
+	}
+	for _, tt := range tests {
+		if _, err := crypto.VerifyPassword(tt.encoded, []byte("password")); !errors.Is(err, tt.want) {
+			t.Errorf("VerifyPassword(%q) err = %v, want %v", tt.encoded, err, tt.want)
+		}
+		if _, err := crypto.NeedsRehash(tt.encoded, nil); !errors.Is(err, tt.want) {
+			t.Errorf("NeedsRehash(%q) err = %v, want %v", tt.encoded, err, tt.want)
+		}
+	}
+
+	if _, err := crypto.HashPassword([]byte("password"), &crypto.Argon2Params{Memory: 64, Time: 0, Threads: 1}); err == nil {
+		t.Error("HashPassword() with time 0 succeeded")
+	}
+}
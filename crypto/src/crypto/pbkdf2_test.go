@@ -12,8 +12,6 @@
 package crypto_test
 
 import (
-	"crypto/pbkdf2"
-	"crypto/sha3"
 	"encoding/hex"
 	"testing"
 
@@ -30,7 +28,7 @@ func Pbkdf2(password string, keyLen int) (key, salt []byte, err error) {
 	salt, _ = crypto.RandomBytes(32) // 推荐长度 >16, 保证唯一性
 
 	// sha3-512 比 sha256(sha2-256) 慢 5~6 倍.
-	key, err = pbkdf2.Key(sha3.New512, password, salt, iter, keyLen)
+	key, err = crypto.NewPBKDF2SHA3512(iter, keyLen).Derive([]byte(password), salt)
 	return key, salt, err
 }
 
@@ -0,0 +1,270 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Chunked AEAD stream, STREAM construction (Hoang, Reyhanitabar, Rogaway, Vizár: "Online Authenticated-Encryption and its Nonce-Reuse Misuse-Resistance").
+//
+// 每个 stream 使用 HKDF-SHA256(key, salt) 派生自己的 key (和 Tink 的 AES-GCM-HKDF streaming AEAD 相同),
+// salt 是 header 中 32 bytes 的随机数, 所以同一个 key 可以加密任意多个 stream, 不需要担心 7 bytes nonce prefix 的碰撞.
+//
+// plaintext 被分成固定大小的 segment, 每个 segment 使用 AES-GCM 单独加密, nonce 为:
+//
+//	nonce prefix (7 bytes, random) | counter (4 bytes, big endian) | last flag (1 byte, 1 for the final segment)
+//
+// counter 保证 segment 不能被重新排序, last flag 保证 stream 不能在 segment 边界被截断.
+// 最后一个 segment 可以小于 segment size, 也可以为空, 所以每个 stream 至少有一个 segment.
+//
+// Format:
+//
+//	magic "GCMS" | version u8 | segment size u32 | salt [32]byte | nonce prefix [7]byte | segment 0 | ... | final segment
+//
+// 每个 segment 是 GCM Seal 的输出 (ciphertext + 16 bytes tag), header 作为每个 segment 的 additionalData.
+const (
+	streamMagic       = "GCMS"
+	streamVersion     = 1
+	streamHeaderSize  = streamSaltOffset + streamSaltSize + streamPrefixSize
+	streamSaltOffset  = len(streamMagic) + 1 + 4
+	streamSaltSize    = 32
+	streamPrefixSize  = 7
+	streamKeyInfo     = "GCMS stream key"
+	streamSegmentSize = 64 * 1024
+	maxSegmentSize    = 16 * 1024 * 1024 // rejected by the reader to bound the memory
+)
+
+// streamKey derives the key of the stream whose header contains salt, the derived key has the same size as key.
+func streamKey(key, salt []byte) ([]byte, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, aes.KeySizeError(len(key))
+	}
+	return hkdf.Key(sha256.New, key, salt, streamKeyInfo, len(key))
+}
+
+// streamAEAD returns the AES-GCM of the stream with header.
+func streamAEAD(key, header []byte) (cipher.AEAD, error) {
+	k, err := streamKey(key, header[streamSaltOffset:streamSaltOffset+streamSaltSize])
+	if err != nil {
+		return nil, err
+	}
+	return newAESGCM(k)
+}
+
+// streamNonce fills nonce with the nonce of segment i.
+func streamNonce(nonce, prefix []byte, i uint32, last bool) {
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[streamPrefixSize:], i)
+	nonce[len(nonce)-1] = 0
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+}
+
+type encryptWriter struct {
+	w       io.Writer
+	aead    cipher.AEAD
+	header  []byte
+	buf     []byte // plaintext of the current segment
+	out     []byte
+	nonce   []byte
+	counter uint32
+	err     error
+}
+
+// NewEncryptWriter returns a writer which encrypts the data written to it and writes the stream to w.
+// The header is written immediately, Close must be called to write the final segment, it does not close w.
+// key must be 16, 24 or 32 bytes.
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	random, err := RandomBytes(streamSaltSize + streamPrefixSize) // salt | nonce prefix
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, streamHeaderSize)
+	header = append(header, streamMagic...)
+	header = append(header, streamVersion)
+	header = binary.BigEndian.AppendUint32(header, streamSegmentSize)
+	header = append(header, random...)
+
+	aead, err := streamAEAD(key, header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return &encryptWriter{
+		w:      w,
+		aead:   aead,
+		header: header,
+		buf:    make([]byte, 0, streamSegmentSize),
+		out:    make([]byte, 0, streamSegmentSize+aead.Overhead()),
+		nonce:  make([]byte, aead.NonceSize()),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (n int, err error) {
+	if e.err != nil {
+		return 0, e.err
+	}
+	for len(p) > 0 {
+		if len(e.buf) == streamSegmentSize {
+			// more data follows, so the full segment is not the last one.
+			if err := e.seal(false); err != nil {
+				return n, err
+			}
+		}
+		m := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close writes the final segment.
+func (e *encryptWriter) Close() error {
+	if e.err != nil {
+		if errors.Is(e.err, errStreamClosed) {
+			return nil
+		}
+		return e.err
+	}
+	if err := e.seal(true); err != nil {
+		return err
+	}
+	e.err = errStreamClosed
+	return nil
+}
+
+var errStreamClosed = errors.New("write to closed stream")
+
+func (e *encryptWriter) seal(last bool) error {
+	streamNonce(e.nonce, e.header[len(e.header)-streamPrefixSize:], e.counter, last)
+	e.out = e.aead.Seal(e.out[:0], e.nonce, e.buf, e.header)
+	if _, err := e.w.Write(e.out); err != nil {
+		e.err = err
+		return err
+	}
+	if e.counter == math.MaxUint32 && !last {
+		e.err = errors.New("stream is too long")
+		return e.err
+	}
+	e.counter++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+type decryptReader struct {
+	r       io.Reader
+	aead    cipher.AEAD
+	header  []byte
+	in      []byte // ciphertext of the current segment, with one byte read ahead
+	buf     []byte // plaintext of the current segment
+	plain   []byte // part of buf not read yet
+	nonce   []byte
+	counter uint32
+	err     error
+}
+
+// NewDecryptReader returns a reader which decrypts the stream written by NewEncryptWriter.
+// Read never returns unauthenticated data: tampered, reordered or truncated segments return ErrAuthFailed,
+// an invalid header returns ErrInvalidStreamHeader.
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	// check the key size before reading r
+	if _, err := streamKey(key, nil); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidStreamHeader, err)
+	}
+	if !bytes.HasPrefix(header, []byte(streamMagic)) {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidStreamHeader)
+	}
+	if v := header[len(streamMagic)]; v != streamVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrUnsupportedVersion, v)
+	}
+	segmentSize := binary.BigEndian.Uint32(header[len(streamMagic)+1:])
+	if segmentSize == 0 || segmentSize > maxSegmentSize {
+		return nil, fmt.Errorf("%w: segment size %d", ErrInvalidStreamHeader, segmentSize)
+	}
+	aead, err := streamAEAD(key, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptReader{
+		r:      r,
+		aead:   aead,
+		header: header,
+		in:     make([]byte, 0, int(segmentSize)+aead.Overhead()+1),
+		buf:    make([]byte, 0, segmentSize),
+		nonce:  make([]byte, aead.NonceSize()),
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.err = d.next()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// next reads and decrypts the next segment into d.plain.
+// One byte after the segment is read ahead, the segment is the last one if the stream ends before that byte.
+func (d *decryptReader) next() error {
+	// d.in holds the byte read ahead from the previous segment
+	n, err := io.ReadFull(d.r, d.in[len(d.in):cap(d.in)])
+	d.in = d.in[:len(d.in)+n]
+	last := false
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		last = true
+	default:
+		return err
+	}
+
+	segment := d.in
+	if !last {
+		segment = d.in[:len(d.in)-1]
+	}
+
+	streamNonce(d.nonce, d.header[len(d.header)-streamPrefixSize:], d.counter, last)
+	plain, err := d.aead.Open(d.buf[:0], d.nonce, segment, d.header)
+	if err != nil {
+		return ErrAuthFailed
+	}
+	d.plain = plain
+
+	if last {
+		return io.EOF
+	}
+	if d.counter == math.MaxUint32 {
+		return ErrAuthFailed
+	}
+	d.counter++
+
+	// keep the byte read ahead
+	d.in = append(d.in[:0], d.in[len(d.in)-1])
+	return nil
+}
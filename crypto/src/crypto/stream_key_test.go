@@ -0,0 +1,35 @@
+package crypto
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStreamKey(t *testing.T) {
+	key, _ := RandomBytes(32)
+
+	// two streams under the same key
+	var keys [][]byte
+	for range 2 {
+		var buf bytes.Buffer
+		w, err := NewEncryptWriter(&buf, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		salt := buf.Bytes()[streamSaltOffset : streamSaltOffset+streamSaltSize]
+		k, err := streamKey(key, salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(k) != len(key) || bytes.Equal(k, key) {
+			t.Fatalf("derived key %x, key %x", k, key)
+		}
+		keys = append(keys, k)
+	}
+	if bytes.Equal(keys[0], keys[1]) {
+		t.Error("two streams use the same derived key")
+	}
+}
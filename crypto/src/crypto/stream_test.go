@@ -0,0 +1,181 @@
+package crypto_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"local/src/crypto"
+)
+
+const (
+	segmentSize   = 64 * 1024
+	headerSize    = 4 + 1 + 4 + 32 + 7
+	sealedSegment = segmentSize + 16 // GCM tag
+)
+
+func encryptStream(t *testing.T, key, plaintext []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := crypto.NewEncryptWriter(&buf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decryptStream(key, stream []byte) ([]byte, error) {
+	r, err := crypto.NewDecryptReader(bytes.NewReader(stream), key)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestStreamRoundTrip(t *testing.T) {
+	key, _ := crypto.RandomBytes(32)
+
+	for _, n := range []int{0, 1, 100, segmentSize - 1, segmentSize, segmentSize + 1, 3 * segmentSize, 3*segmentSize + 7} {
+		plaintext, _ := crypto.RandomBytes(n)
+		stream := encryptStream(t, key, plaintext)
+
+		segments := max(1, (n+segmentSize-1)/segmentSize) // the last segment could be full
+		if want := headerSize + n + segments*16; len(stream) != want {
+			t.Errorf("%d bytes: stream is %d bytes, want %d", n, len(stream), want)
+		}
+
+		got, err := decryptStream(key, stream)
+		if err != nil || !bytes.Equal(got, plaintext) {
+			t.Errorf("%d bytes: decrypt err = %v, equal = %v", n, err, bytes.Equal(got, plaintext))
+		}
+
+		// small reads from both sides
+		r, err := crypto.NewDecryptReader(iotest.OneByteReader(bytes.NewReader(stream)), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := iotest.TestReader(iotest.HalfReader(r), plaintext); err != nil {
+			t.Errorf("%d bytes: %v", n, err)
+		}
+	}
+}
+
+func TestStreamSmallWrites(t *testing.T) {
+	key, _ := crypto.RandomBytes(16)
+	plaintext, _ := crypto.RandomBytes(2*segmentSize + 10)
+
+	var buf bytes.Buffer
+	w, err := crypto.NewEncryptWriter(&buf, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(plaintext); i += 1000 {
+		if _, err := w.Write(plaintext[i:min(i+1000, len(plaintext))]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+	if _, err := w.Write([]byte("x")); err == nil {
+		t.Error("Write() after Close() succeeded")
+	}
+
+	got, err := decryptStream(key, buf.Bytes())
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("decrypt err = %v, equal = %v", err, bytes.Equal(got, plaintext))
+	}
+}
+
+func TestStreamTampered(t *testing.T) {
+	key, _ := crypto.RandomBytes(32)
+	plaintext, _ := crypto.RandomBytes(3*segmentSize + 100)
+	stream := encryptStream(t, key, plaintext)
+	seg := func(i int) []byte {
+		start := headerSize + i*sealedSegment
+		return stream[start:min(start+sealedSegment, len(stream))]
+	}
+	concat := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	header := stream[:headerSize]
+
+	flip := func(i int) []byte {
+		b := bytes.Clone(stream)
+		b[i] ^= 1
+		return b
+	}
+	otherKey, _ := crypto.RandomBytes(32)
+	otherStream := encryptStream(t, key, plaintext)
+
+	tests := []struct {
+		name   string
+		stream []byte
+		key    []byte
+	}{
+		{"flip prefix", flip(headerSize - 1), key},
+		{"flip segment size", flip(8), key},
+		{"flip salt", flip(9), key},
+		{"flip first segment", flip(headerSize), key},
+		{"flip last segment", flip(len(stream) - 1), key},
+		{"truncate at segment boundary", stream[:headerSize+2*sealedSegment], key},
+		{"truncate in segment", stream[:headerSize+2*sealedSegment+100], key},
+		{"truncate last byte", stream[:len(stream)-1], key},
+		{"drop last segment", concat(header, seg(0), seg(1), seg(2)), key},
+		{"drop segment", concat(header, seg(0), seg(2), seg(3)), key},
+		{"reorder segments", concat(header, seg(1), seg(0), seg(2), seg(3)), key},
+		{"append segment", concat(stream, seg(3)), key},
+		{"segment of other stream", concat(header, otherStream[headerSize:headerSize+sealedSegment], seg(1), seg(2), seg(3)), key},
+		{"no segment", header, key},
+		{"wrong key", stream, otherKey},
+	}
+	for _, tt := range tests {
+		got, err := decryptStream(tt.key, tt.stream)
+		if !errors.Is(err, crypto.ErrAuthFailed) {
+			t.Errorf("%s: err = %v, want ErrAuthFailed", tt.name, err)
+		}
+		// only authenticated segments are returned
+		if !bytes.HasPrefix(plaintext, got) || len(got)%segmentSize != 0 {
+			t.Errorf("%s: returned %d bytes of unauthenticated data", tt.name, len(got))
+		}
+	}
+}
+
+func TestStreamInvalidHeader(t *testing.T) {
+	key, _ := crypto.RandomBytes(32)
+	stream := encryptStream(t, key, []byte("this is a stream test!!!"))
+
+	tests := []struct {
+		name   string
+		modify func(b []byte) []byte
+		want   error
+	}{
+		{"empty", func(b []byte) []byte { return nil }, crypto.ErrInvalidStreamHeader},
+		{"short", func(b []byte) []byte { return b[:headerSize-1] }, crypto.ErrInvalidStreamHeader},
+		{"magic", func(b []byte) []byte { b[0] = 'X'; return b }, crypto.ErrInvalidStreamHeader},
+		{"version", func(b []byte) []byte { b[4] = 2; return b }, crypto.ErrUnsupportedVersion},
+		{"segment size 0", func(b []byte) []byte { copy(b[5:], []byte{0, 0, 0, 0}); return b }, crypto.ErrInvalidStreamHeader},
+		{"segment size too large", func(b []byte) []byte { copy(b[5:], []byte{0xff, 0, 0, 0}); return b }, crypto.ErrInvalidStreamHeader},
+	}
+	for _, tt := range tests {
+		b := tt.modify(bytes.Clone(stream))
+		if _, err := crypto.NewDecryptReader(bytes.NewReader(b), key); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := crypto.NewEncryptWriter(io.Discard, []byte("short key")); err == nil {
+		t.Error("NewEncryptWriter() with invalid key succeeded")
+	}
+}
@@ -15,24 +15,35 @@ type ITesting interface {
 	Errorf(string, ...any)
 }
 
-func usecase(t ITesting, password, plain_orig []byte, algo string) {
+func usecase(t ITesting, password, plain_orig []byte) {
 	params := &crypto.Argon2Params{
 		Memory:  64, // KB, 减少计算量
 		Time:    16, // iterations
 		Threads: 4,
-		KeyLen:  32, // key 长度, 如果要配合 AES 则需要使用 16|24|32
 	}
 
 	fSize := 6
 
-	rec, err := Argon2AESEncrypt(password, plain_orig, params, algo, fSize)
+	enc, err := Encode(plain_orig, fSize)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	env, err := crypto.SealWithPassword(password, enc, params)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 
 	// 解密
-	plain_dec, err := Argon2AESDecrypt(password, rec, fSize)
+	dec, err := crypto.OpenWithPassword(password, env)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	plain_dec, err := Decode(dec, fSize)
 	if err != nil {
 		t.Error(err)
 		return
@@ -45,35 +56,11 @@ func usecase(t ITesting, password, plain_orig []byte, algo string) {
 }
 
 func BenchmarkUsercase(b *testing.B) {
-	var (
-		GCM int
-		CTR int
-		CBC int
-	)
-
 	for b.Loop() {
 		password, _ := crypto.RandomBytes(rand.IntN(12) + 6)
 		plain_byts, _ := crypto.RandomBytes(rand.IntN(107) + 1)
 
-		algoInt := rand.UintN(100) % 3
-		var algoStr string
-		switch algoInt {
-		case 0:
-			GCM += 1
-			algoStr = "GCM"
-		case 1:
-			CTR += 1
-			algoStr = "CTR"
-		case 2:
-			CBC += 1
-			algoStr = "CBC"
-		default:
-			b.Errorf("algo error: %d", algoInt)
-			return
-		}
-
-		usecase(b, password, plain_byts, algoStr)
+		usecase(b, password, plain_byts)
 	}
 	b.ReportAllocs() // go test -benchmem
-	b.Logf("GCM: %d, CTR: %d, CBC: %d", GCM, CTR, CBC)
 }
@@ -0,0 +1,257 @@
+package avltree
+
+import (
+	"cmp"
+	"iter"
+)
+
+// Node represents a node in the AVL tree
+type Node[K cmp.Ordered, V any] struct {
+	Key    K
+	Value  V
+	Height int // height of the subtree rooted at this node, leaf node is 1
+	Left   *Node[K, V]
+	Right  *Node[K, V]
+}
+
+// Tree represents an AVL tree, the heights of the two child subtrees of any node differ by at most one.
+//
+// 和 RBTree 相比, AVL tree 更严格平衡, lookup 更快, 但 insert/delete 需要更多的 rotation.
+type Tree[K cmp.Ordered, V any] struct {
+	Root *Node[K, V]
+	size int
+}
+
+// New creates a new AVL tree
+func New[K cmp.Ordered, V any]() *Tree[K, V] {
+	return &Tree[K, V]{}
+}
+
+// Get returns the value of the given key, and whether the key is found.
+func (t *Tree[K, V]) Get(key K) (V, bool) {
+	x := t.Root
+	for x != nil {
+		switch {
+		case key < x.Key:
+			x = x.Left
+		case key > x.Key:
+			x = x.Right
+		default:
+			return x.Value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Put adds or replaces the value of key.
+func (t *Tree[K, V]) Put(key K, value V) {
+	t.Root = t.put(t.Root, key, value)
+}
+
+func (t *Tree[K, V]) put(x *Node[K, V], key K, value V) *Node[K, V] {
+	if x == nil {
+		t.size++
+		return &Node[K, V]{Key: key, Value: value, Height: 1}
+	}
+
+	switch {
+	case key < x.Key:
+		x.Left = t.put(x.Left, key, value)
+	case key > x.Key:
+		x.Right = t.put(x.Right, key, value)
+	default:
+		// Key already exists, update value and return
+		x.Value = value
+		return x
+	}
+
+	return rebalance(x)
+}
+
+// Delete removes key from the tree, returns false if the key is not found.
+func (t *Tree[K, V]) Delete(key K) bool {
+	var deleted bool
+	t.Root = t.delete(t.Root, key, &deleted)
+	if deleted {
+		t.size--
+	}
+	return deleted
+}
+
+func (t *Tree[K, V]) delete(x *Node[K, V], key K, deleted *bool) *Node[K, V] {
+	if x == nil {
+		return nil
+	}
+
+	switch {
+	case key < x.Key:
+		x.Left = t.delete(x.Left, key, deleted)
+	case key > x.Key:
+		x.Right = t.delete(x.Right, key, deleted)
+	default:
+		*deleted = true
+		if x.Left == nil {
+			return x.Right
+		}
+		if x.Right == nil {
+			return x.Left
+		}
+
+		// x has two children, replace x with its successor, 即:右子树中最小的节点.
+		successor := x.Right
+		for successor.Left != nil {
+			successor = successor.Left
+		}
+		successor.Right = deleteMin(x.Right)
+		successor.Left = x.Left
+		x = successor
+	}
+
+	return rebalance(x)
+}
+
+// deleteMin removes the smallest node of the subtree rooted at x, returns the new subtree root.
+func deleteMin[K cmp.Ordered, V any](x *Node[K, V]) *Node[K, V] {
+	if x.Left == nil {
+		return x.Right
+	}
+	x.Left = deleteMin(x.Left)
+	return rebalance(x)
+}
+
+// Len returns the number of nodes in the tree.
+func (t *Tree[K, V]) Len() int {
+	return t.size
+}
+
+// Min returns the smallest key and its value, returns false if the tree is empty.
+func (t *Tree[K, V]) Min() (K, V, bool) {
+	if t.Root == nil {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	x := t.Root
+	for x.Left != nil {
+		x = x.Left
+	}
+	return x.Key, x.Value, true
+}
+
+// Max returns the largest key and its value, returns false if the tree is empty.
+func (t *Tree[K, V]) Max() (K, V, bool) {
+	if t.Root == nil {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	x := t.Root
+	for x.Right != nil {
+		x = x.Right
+	}
+	return x.Key, x.Value, true
+}
+
+// Range returns an iterator over keys in [lo, hi) in ascending order.
+// The tree must not be modified during iteration.
+func (t *Tree[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.Root, &lo, &hi, yield)
+	}
+}
+
+// All returns an iterator over all keys in ascending order.
+func (t *Tree[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		t.ascend(t.Root, nil, nil, yield)
+	}
+}
+
+// ascend visits keys in [lo, hi) of the subtree rooted at x, nil lo or hi means no bound.
+// returns false if yield returns false.
+func (t *Tree[K, V]) ascend(x *Node[K, V], lo, hi *K, yield func(K, V) bool) bool {
+	if x == nil {
+		return true
+	}
+	if lo == nil || *lo < x.Key {
+		if !t.ascend(x.Left, lo, hi, yield) {
+			return false
+		}
+	}
+	if (lo == nil || *lo <= x.Key) && (hi == nil || x.Key < *hi) {
+		if !yield(x.Key, x.Value) {
+			return false
+		}
+	}
+	if hi == nil || x.Key < *hi {
+		return t.ascend(x.Right, lo, hi, yield)
+	}
+	return true
+}
+
+// rebalance updates the height of x and performs rotations if x is unbalanced.
+//
+//	LL: rightRotate(x)
+//	LR: leftRotate(x.Left), then rightRotate(x)
+//	RR: leftRotate(x)
+//	RL: rightRotate(x.Right), then leftRotate(x)
+func rebalance[K cmp.Ordered, V any](x *Node[K, V]) *Node[K, V] {
+	updateHeight(x)
+
+	switch bf := balanceFactor(x); {
+	case bf > 1:
+		// left subtree is higher
+		if balanceFactor(x.Left) < 0 {
+			x.Left = leftRotate(x.Left) // LR
+		}
+		return rightRotate(x)
+	case bf < -1:
+		// right subtree is higher
+		if balanceFactor(x.Right) > 0 {
+			x.Right = rightRotate(x.Right) // RL
+		}
+		return leftRotate(x)
+	}
+	return x
+}
+
+// leftRotate performs a left rotation on x, returns the new subtree root.
+func leftRotate[K cmp.Ordered, V any](x *Node[K, V]) *Node[K, V] {
+	y := x.Right
+	x.Right = y.Left
+	y.Left = x
+	updateHeight(x)
+	updateHeight(y)
+	return y
+}
+
+// rightRotate performs a right rotation on y, returns the new subtree root.
+func rightRotate[K cmp.Ordered, V any](y *Node[K, V]) *Node[K, V] {
+	x := y.Left
+	y.Left = x.Right
+	x.Right = y
+	updateHeight(y)
+	updateHeight(x)
+	return x
+}
+
+func height[K cmp.Ordered, V any](x *Node[K, V]) int {
+	if x == nil {
+		return 0
+	}
+	return x.Height
+}
+
+func updateHeight[K cmp.Ordered, V any](x *Node[K, V]) {
+	x.Height = 1 + max(height(x.Left), height(x.Right))
+}
+
+// balanceFactor is height(left) - height(right)
+func balanceFactor[K cmp.Ordered, V any](x *Node[K, V]) int {
+	return height(x.Left) - height(x.Right)
+}
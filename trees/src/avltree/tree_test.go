@@ -0,0 +1,39 @@
+package avltree
+
+import (
+	"math/rand/v2"
+	"testing"
+
+	"local/src/orderedmap"
+	"local/src/orderedmap/orderedmaptest"
+)
+
+func TestOrderedMap(t *testing.T) {
+	orderedmaptest.Run(t, func() orderedmap.OrderedMap[int, any] { return New[int, any]() })
+}
+
+func TestBalanced(t *testing.T) {
+	tree := New[int, string]()
+	for i := range 1000 {
+		tree.Put(i, "")
+	}
+	for _, k := range rand.Perm(1000)[:500] {
+		tree.Delete(k)
+	}
+
+	var check func(x *Node[int, string]) int
+	check = func(x *Node[int, string]) int {
+		if x == nil {
+			return 0
+		}
+		l, r := check(x.Left), check(x.Right)
+		if l-r > 1 || r-l > 1 {
+			t.Fatalf("node %d is unbalanced: left %d, right %d", x.Key, l, r)
+		}
+		if x.Height != 1+max(l, r) {
+			t.Fatalf("node %d height %d, want %d", x.Key, x.Height, 1+max(l, r))
+		}
+		return x.Height
+	}
+	t.Log("height:", check(tree.Root))
+}
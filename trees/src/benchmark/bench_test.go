@@ -0,0 +1,295 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"local/src/avltree"
+	"local/src/bplustree"
+	"local/src/orderedmap"
+	"local/src/redblacktree"
+	"local/src/skiplist"
+)
+
+var (
+	sizes  = []int{1e3, 1e4, 1e5, 1e6}
+	orders = []int{4, 16, 64, 256}
+	dists  = []string{"seq", "random", "zipf"}
+)
+
+// orderedSet is the common operations of the benchmarked structures.
+type orderedSet interface {
+	insert(key int)
+	get(key int) bool
+	delete(key int)
+	scan(from, n int) int
+}
+
+type impl struct {
+	name string
+	new  func() orderedSet
+
+	canScan   bool
+	maxInsert int // sorted slice insert is O(n), skip the larger sizes
+}
+
+func impls() []impl {
+	list := []impl{
+		{name: "rbtree", new: func() orderedSet { return rbSet{redblacktree.NewRBTree()} }, canScan: true},
+		{name: "avltree", new: func() orderedSet { return mapOf{avltree.New[int, any]()} }, canScan: true},
+		{name: "skiplist", new: func() orderedSet { return mapOf{skiplist.New[int, any]()} }, canScan: true},
+	}
+	for _, order := range orders {
+		list = append(list, impl{
+			name:    fmt.Sprintf("bplustree-%d", order),
+			new:     func() orderedSet { return bplusSet{bplustree.NewBPlusTreeWithOrder(order)} },
+			canScan: true,
+		})
+	}
+	return append(list,
+		impl{name: "slice", new: func() orderedSet { return &sliceSet{} }, canScan: true, maxInsert: 1e5},
+		impl{name: "map", new: func() orderedSet { return mapSet{} }},
+	)
+}
+
+// keys returns n keys in [0, n) of the given distribution.
+// zipf keys are skewed to a few hot keys, hot keys are scattered by a permutation.
+func keys(dist string, n int, r *rand.Rand) []int {
+	ks := make([]int, n)
+	switch dist {
+	case "seq":
+		for i := range ks {
+			ks[i] = i
+		}
+	case "random":
+		ks = r.Perm(n)
+	case "zipf":
+		perm := r.Perm(n)
+		z := rand.NewZipf(r, 1.1, 1, uint64(n-1))
+		for i := range ks {
+			ks[i] = perm[z.Uint64()]
+		}
+	default:
+		panic("unknown distribution " + dist)
+	}
+	return ks
+}
+
+// build returns a set of the given implementation filled with keys [0, n).
+func build(im impl, n int, r *rand.Rand) orderedSet {
+	s := im.new()
+	if sl, ok := s.(*sliceSet); ok {
+		// sorted slice is built directly, random inserts would take too long.
+		sl.keys = make([]int, n)
+		for i := range n {
+			sl.keys[i] = i
+		}
+		return s
+	}
+	for _, k := range r.Perm(n) {
+		s.insert(k)
+	}
+	return s
+}
+
+func newRand() *rand.Rand {
+	return rand.New(rand.NewPCG(1, 2)) //nolint:gosec // deterministic keys for benchmarks.
+}
+
+// BenchmarkInsert builds a structure of n keys in every iteration, ns/key is the cost of one insert.
+func BenchmarkInsert(b *testing.B) {
+	for _, dist := range dists {
+		for _, im := range impls() {
+			for _, n := range sizes {
+				if im.maxInsert > 0 && n > im.maxInsert {
+					continue
+				}
+				b.Run(fmt.Sprintf("dist=%s/impl=%s/n=%d", dist, im.name, n), func(b *testing.B) {
+					ks := keys(dist, n, newRand())
+					for b.Loop() {
+						s := im.new()
+						for _, k := range ks {
+							s.insert(k)
+						}
+					}
+					b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/key")
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	for _, dist := range dists {
+		for _, im := range impls() {
+			for _, n := range sizes {
+				b.Run(fmt.Sprintf("dist=%s/impl=%s/n=%d", dist, im.name, n), func(b *testing.B) {
+					r := newRand()
+					s := build(im, n, r)
+					ks := keys(dist, n, r)
+					i := 0
+					for b.Loop() {
+						if !s.get(ks[i%n]) {
+							b.Fatal("key not found")
+						}
+						i++
+					}
+				})
+			}
+		}
+	}
+}
+
+// BenchmarkDelete deletes keys in random order, the structure is rebuilt with the timer stopped when it is empty.
+func BenchmarkDelete(b *testing.B) {
+	for _, im := range impls() {
+		for _, n := range sizes {
+			b.Run(fmt.Sprintf("impl=%s/n=%d", im.name, n), func(b *testing.B) {
+				r := newRand()
+				s := build(im, n, r)
+				ks := keys("random", n, r)
+				i := 0
+				for b.Loop() {
+					if i == n {
+						b.StopTimer()
+						s = build(im, n, r)
+						i = 0
+						b.StartTimer()
+					}
+					s.delete(ks[i])
+					i++
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkRangeScan scans 100 keys from a random start key.
+func BenchmarkRangeScan(b *testing.B) {
+	const length = 100
+	for _, im := range impls() {
+		if !im.canScan {
+			continue
+		}
+		for _, n := range sizes {
+			b.Run(fmt.Sprintf("impl=%s/n=%d", im.name, n), func(b *testing.B) {
+				r := newRand()
+				s := build(im, n, r)
+				for b.Loop() {
+					s.scan(r.IntN(n-length), length)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkMixed runs 90% lookups and 10% inserts of random keys in [0, 2n).
+func BenchmarkMixed(b *testing.B) {
+	for _, im := range impls() {
+		for _, n := range sizes {
+			if im.maxInsert > 0 && n > im.maxInsert {
+				continue
+			}
+			b.Run(fmt.Sprintf("impl=%s/n=%d", im.name, n), func(b *testing.B) {
+				r := newRand()
+				s := build(im, n, r)
+				for b.Loop() {
+					k := r.IntN(2 * n)
+					if r.IntN(10) == 0 {
+						s.insert(k)
+					} else {
+						s.get(k)
+					}
+				}
+			})
+		}
+	}
+}
+
+type rbSet struct{ t *redblacktree.RBTree }
+
+func (s rbSet) insert(key int)   { s.t.Insert(key, nil) }
+func (s rbSet) get(key int) bool { _, ok := s.t.Get(key); return ok }
+func (s rbSet) delete(key int)   { s.t.Delete(key) }
+func (s rbSet) scan(from, n int) int {
+	count := 0
+	for range s.t.Ascend(from) {
+		count++
+		if count == n {
+			break
+		}
+	}
+	return count
+}
+
+type bplusSet struct{ t *bplustree.BPlusTree }
+
+func (s bplusSet) insert(key int)   { _ = s.t.Insert(key) }
+func (s bplusSet) get(key int) bool { _, err := s.t.Search(key); return err == nil }
+func (s bplusSet) delete(key int)   { s.t.Delete(key) }
+func (s bplusSet) scan(from, n int) int {
+	count := 0
+	for range s.t.Ascend(from) {
+		count++
+		if count == n {
+			break
+		}
+	}
+	return count
+}
+
+// mapOf adapts any orderedmap.OrderedMap.
+type mapOf struct {
+	m orderedmap.OrderedMap[int, any]
+}
+
+func (s mapOf) insert(key int)   { s.m.Put(key, nil) }
+func (s mapOf) get(key int) bool { _, ok := s.m.Get(key); return ok }
+func (s mapOf) delete(key int)   { s.m.Delete(key) }
+func (s mapOf) scan(from, n int) int {
+	count := 0
+	for range s.m.Range(from, math.MaxInt) {
+		count++
+		if count == n {
+			break
+		}
+	}
+	return count
+}
+
+type sliceSet struct{ keys []int }
+
+func (s *sliceSet) insert(key int) {
+	i, found := slices.BinarySearch(s.keys, key)
+	if !found {
+		s.keys = slices.Insert(s.keys, i, key)
+	}
+}
+
+func (s *sliceSet) get(key int) bool {
+	_, found := slices.BinarySearch(s.keys, key)
+	return found
+}
+
+func (s *sliceSet) delete(key int) {
+	if i, found := slices.BinarySearch(s.keys, key); found {
+		s.keys = slices.Delete(s.keys, i, i+1)
+	}
+}
+
+func (s *sliceSet) scan(from, n int) int {
+	i, _ := slices.BinarySearch(s.keys, from)
+	return len(s.keys[i:min(i+n, len(s.keys))])
+}
+
+type mapSet map[int]struct{}
+
+func (s mapSet) insert(key int)   { s[key] = struct{}{} }
+func (s mapSet) get(key int) bool { _, ok := s[key]; return ok }
+func (s mapSet) delete(key int)   { delete(s, key) }
+func (s mapSet) scan(int, int) int {
+	panic("map is not ordered")
+}
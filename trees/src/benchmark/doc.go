@@ -0,0 +1,10 @@
+// Package benchmark compares RBTree, BPlusTree, AVL tree, skip list, sorted slice and the builtin map.
+//
+// Run the suite and compare results with benchstat:
+//
+//	go test -run=^$ -bench=. -count=10 ./benchmark | tee new.txt
+//	benchstat old.txt new.txt
+//
+// Use -bench 'Lookup/dist=zipf' to select a workload, sub-benchmark names are in
+// key=value form so that benchstat can group them, eg: 'benchstat -col /impl new.txt'.
+package benchmark
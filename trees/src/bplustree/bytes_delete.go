@@ -0,0 +1,149 @@
+package bplustree
+
+import (
+	"bytes"
+	"slices"
+)
+
+// bytesMinKeys is the minimum number of keys of a non-root BytesNode, the same as BPlusTree.minKeys.
+const bytesMinKeys = (defaultOrder - 1) / 2
+
+// Delete removes key from the tree, returns ErrKeyNotFound if the key does not exist.
+//
+// rebalance 和 BPlusTree.Delete 相同, 区别是 leaf 的 key 是前缀压缩的:
+// borrow 和 merge 都使用完整的 key, 之后重新计算 Prefix, parent 中的 separator 使用 shortestSeparator.
+func (t *BytesTree) Delete(key []byte) error {
+	leaf := t.findLeafNode(key)
+
+	found := false
+	i := 0
+	if bytes.HasPrefix(key, leaf.Prefix) {
+		i, found = leaf.search(key)
+	}
+	if !found {
+		return &KeyError{Op: "delete", Key: slices.Clone(key), Err: ErrKeyNotFound}
+	}
+
+	leaf.Keys = slices.Delete(leaf.Keys, i, i+1)
+	t.rebalance(leaf)
+	return nil
+}
+
+// rebalance fixes the node after a key is removed from it.
+func (t *BytesTree) rebalance(node *BytesNode) {
+	if node.Parent == nil {
+		// root node can have less keys, an empty internal root is replaced by its only child.
+		if !node.IsLeaf && len(node.Keys) == 0 {
+			t.Root = node.Children[0]
+			t.Root.Parent = nil
+			node.Children = nil
+		}
+		return
+	}
+
+	if len(node.Keys) >= bytesMinKeys {
+		return
+	}
+
+	parent := node.Parent
+	idx := slices.Index(parent.Children, node)
+
+	var left, right *BytesNode
+	if idx > 0 {
+		left = parent.Children[idx-1]
+	}
+	if idx < len(parent.Children)-1 {
+		right = parent.Children[idx+1]
+	}
+
+	switch {
+	case left != nil && len(left.Keys) > bytesMinKeys:
+		borrowBytesFromLeft(node, left, idx)
+	case right != nil && len(right.Keys) > bytesMinKeys:
+		borrowBytesFromRight(node, right, idx)
+	case left != nil:
+		mergeBytesNodes(left, node, idx-1)
+		t.rebalance(parent)
+	default:
+		mergeBytesNodes(node, right, idx)
+		t.rebalance(parent)
+	}
+}
+
+// borrowBytesFromLeft moves the last key of left sibling to node, idx is the index of node in its parent.
+func borrowBytesFromLeft(node, left *BytesNode, idx int) {
+	parent := node.Parent
+	last := len(left.Keys) - 1
+
+	if node.IsLeaf {
+		node.insertLeafKey(left.Key(last))
+		left.Keys[last] = nil
+		left.Keys = left.Keys[:last]
+		parent.Keys[idx-1] = shortestSeparator(left.Key(last-1), node.Key(0))
+		return
+	}
+
+	// separator moves down to node, the last key of left moves up to parent.
+	child := left.Children[last+1]
+	node.Keys = slices.Insert(node.Keys, 0, parent.Keys[idx-1])
+	node.Children = slices.Insert(node.Children, 0, child)
+	child.Parent = node
+	parent.Keys[idx-1] = left.Keys[last]
+	left.Keys[last] = nil
+	left.Keys = left.Keys[:last]
+	left.Children[last+1] = nil
+	left.Children = left.Children[:last+1]
+}
+
+// borrowBytesFromRight moves the first key of right sibling to node, idx is the index of node in its parent.
+func borrowBytesFromRight(node, right *BytesNode, idx int) {
+	parent := node.Parent
+
+	if node.IsLeaf {
+		node.insertLeafKey(right.Key(0))
+		right.Keys = slices.Delete(right.Keys, 0, 1)
+		parent.Keys[idx] = shortestSeparator(node.Key(len(node.Keys)-1), right.Key(0))
+		return
+	}
+
+	// separator moves down to node, the first key of right moves up to parent.
+	child := right.Children[0]
+	node.Keys = append(node.Keys, parent.Keys[idx])
+	node.Children = append(node.Children, child)
+	child.Parent = node
+	parent.Keys[idx] = right.Keys[0]
+	right.Keys = slices.Delete(right.Keys, 0, 1)
+	right.Children = slices.Delete(right.Children, 0, 1)
+}
+
+// mergeBytesNodes moves all keys of right into left, and removes the separator parent.Keys[sepIdx] and right from parent.
+func mergeBytesNodes(left, right *BytesNode, sepIdx int) {
+	parent := left.Parent
+
+	if left.IsLeaf {
+		// the merged keys may share a shorter prefix, rebuild it from the full keys.
+		keys := make([][]byte, 0, len(left.Keys)+len(right.Keys))
+		for i := range left.Keys {
+			keys = append(keys, left.Key(i))
+		}
+		for i := range right.Keys {
+			keys = append(keys, right.Key(i))
+		}
+		left.Prefix, left.Keys = nil, keys
+		left.growPrefix()
+		left.Next = right.Next
+	} else {
+		left.Keys = append(left.Keys, parent.Keys[sepIdx])
+		left.Keys = append(left.Keys, right.Keys...)
+		for _, child := range right.Children {
+			child.Parent = left
+		}
+		left.Children = append(left.Children, right.Children...)
+	}
+
+	// NOTE: disconnect right node, for GC purpose.
+	right.Parent, right.Next, right.Children, right.Keys = nil, nil, nil, nil
+
+	parent.Keys = slices.Delete(parent.Keys, sepIdx, sepIdx+1)
+	parent.Children = slices.Delete(parent.Children, sepIdx+1, sepIdx+2)
+}
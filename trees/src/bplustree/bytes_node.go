@@ -0,0 +1,226 @@
+package bplustree
+
+import (
+	"bytes"
+	"slices"
+)
+
+// BytesNode represents a node in the BytesTree.
+//
+// Leaf node 的 key 使用前缀压缩存储: 所有 key 共享 Prefix, Keys 中只保存去掉 Prefix 之后的 suffix.
+// Internal node 的 Keys 保存完整的 separator key, 不做前缀压缩.
+//
+//	Leaf: Prefix = "user:00"
+//	      Keys   = ["01", "02", "17"]  =>  "user:0001", "user:0002", "user:0017"
+type BytesNode struct {
+	IsLeaf   bool
+	Prefix   []byte       // Only used for leaf nodes, common prefix of all keys
+	Keys     [][]byte     // leaf: suffix after Prefix; internal: full separator key
+	Children []*BytesNode // Only used for internal nodes
+	Next     *BytesNode   // Only used for leaf nodes (for range queries)
+	Parent   *BytesNode   // Parent reference
+}
+
+// NewBytesNode creates a new node
+func NewBytesNode(isLeaf bool) *BytesNode {
+	return &BytesNode{
+		IsLeaf:   isLeaf,
+		Keys:     make([][]byte, 0, defaultOrder), // 多一个位置为了 split
+		Children: make([]*BytesNode, 0, defaultOrder+1),
+	}
+}
+
+// Key returns the full i-th key of the node, leaf keys are always returned as a new slice.
+func (node *BytesNode) Key(i int) []byte {
+	if !node.IsLeaf {
+		return node.Keys[i]
+	}
+	key := make([]byte, 0, len(node.Prefix)+len(node.Keys[i]))
+	key = append(key, node.Prefix...)
+	return append(key, node.Keys[i]...)
+}
+
+// search returns the position of key in a leaf node and whether it exists.
+// key must already share the node's Prefix.
+func (node *BytesNode) search(key []byte) (int, bool) {
+	return slices.BinarySearchFunc(node.Keys, key[len(node.Prefix):], bytes.Compare)
+}
+
+// shrinkPrefix shortens the node's Prefix so that key shares it,
+// the removed part of the Prefix is moved back into every suffix.
+func (node *BytesNode) shrinkPrefix(key []byte) {
+	n := commonPrefixLen(node.Prefix, key)
+	if n == len(node.Prefix) {
+		return
+	}
+
+	moved := node.Prefix[n:]
+	for i, suffix := range node.Keys {
+		k := make([]byte, 0, len(moved)+len(suffix))
+		k = append(k, moved...)
+		node.Keys[i] = append(k, suffix...)
+	}
+	node.Prefix = node.Prefix[:n:n]
+}
+
+// growPrefix extends the node's Prefix to the longest prefix shared by all keys.
+// Keys are sorted, so the common prefix of all keys is the common prefix of the first and the last one.
+func (node *BytesNode) growPrefix() {
+	if len(node.Keys) == 0 {
+		return
+	}
+
+	n := commonPrefixLen(node.Keys[0], node.Keys[len(node.Keys)-1])
+	if n == 0 {
+		return
+	}
+
+	prefix := make([]byte, 0, len(node.Prefix)+n)
+	prefix = append(prefix, node.Prefix...)
+	node.Prefix = append(prefix, node.Keys[0][:n]...)
+	for i, suffix := range node.Keys {
+		node.Keys[i] = slices.Clone(suffix[n:])
+	}
+}
+
+// insertLeafKey inserts key into a leaf node, returns false if the key already exists.
+func (node *BytesNode) insertLeafKey(key []byte) bool {
+	if len(node.Keys) == 0 {
+		// empty leaf node, the whole key is the Prefix.
+		node.Prefix = slices.Clone(key)
+		node.Keys = append(node.Keys, []byte{})
+		return true
+	}
+
+	node.shrinkPrefix(key)
+
+	i, found := node.search(key)
+	if found {
+		return false
+	}
+	node.Keys = slices.Insert(node.Keys, i, slices.Clone(key[len(node.Prefix):]))
+	return true
+}
+
+func (node *BytesNode) SplitNode() (newRightNode *BytesNode, promotedKey []byte) {
+	if node.IsLeaf {
+		return splitBytesLeafNode(node)
+	}
+	return splitBytesInternalNode(node)
+}
+
+// splitBytesLeafNode splits a leaf node that has reached maximum capacity.
+// The promoted key is the shortest separator between the two halves (suffix truncation),
+// not the whole first key of the right node.
+func splitBytesLeafNode(node *BytesNode) (newRightNode *BytesNode, promotedKey []byte) {
+	// NOTE: 这里不设置 Parent, 因为 node 可能是 root 节点, 没有 Parent. Parent 设置放在后面.
+	rightNode := NewBytesNode(true)
+
+	// Calculate split point - middle of the node
+	splitIndex := len(node.Keys) / 2
+
+	// Move half of the keys to the new node, both halves start with the old Prefix.
+	rightNode.Prefix = slices.Clone(node.Prefix)
+	rightNode.Keys = append(rightNode.Keys, node.Keys[splitIndex:]...)
+
+	// NOTE: delete underlying suffix from ref, for GC purpose.
+	clear(node.Keys[splitIndex:])
+	node.Keys = node.Keys[:splitIndex]
+
+	// Handle the linked list of leaf nodes for range queries
+	rightNode.Next = node.Next
+	node.Next = rightNode
+
+	// Get the key to promote to the parent, before growPrefix changes the suffixes.
+	promotedKey = shortestSeparator(node.Key(len(node.Keys)-1), rightNode.Key(0))
+
+	// each half may share a longer prefix after split.
+	node.growPrefix()
+	rightNode.growPrefix()
+
+	return rightNode, promotedKey
+}
+
+// splitBytesInternalNode splits an internal node that has reached maximum capacity
+func splitBytesInternalNode(node *BytesNode) (newRightNode *BytesNode, promotedKey []byte) {
+	// NOTE: 这里不设置 Parent, 因为 node 可能是 root 节点, 没有 Parent. Parent 设置放在后面.
+	rightNode := NewBytesNode(false)
+
+	// Calculate split point - middle of the node
+	splitIndex := len(node.Keys) / 2
+
+	// Get the key to promote to the parent
+	promotedKey = node.Keys[splitIndex]
+
+	// Move keys after the middle to the new node (excluding the middle key)
+	rightNode.Keys = append(rightNode.Keys, node.Keys[splitIndex+1:]...)
+
+	// Move corresponding children to the new node
+	rightNode.Children = append(rightNode.Children, node.Children[splitIndex+1:]...)
+
+	// Update parent pointers for moved children
+	for _, child := range rightNode.Children {
+		child.Parent = rightNode
+	}
+
+	// NOTE: delete underlying key & Node from ref, for GC purpose.
+	clear(node.Keys[splitIndex:])
+	node.Keys = node.Keys[:splitIndex]
+	clear(node.Children[splitIndex+1:])
+	node.Children = node.Children[:splitIndex+1]
+
+	return rightNode, promotedKey
+}
+
+// insertBytesIntoParent inserts a separator key and node into the parent node.
+// Unlike insertIntoParent, children are not sorted by Keys[0] because leaf keys are prefix compressed,
+// newRightNode is placed right after oldLeftNode instead.
+func insertBytesIntoParent(oldLeftNode, newRightNode *BytesNode, promotedKey []byte) (newRoot *BytesNode) {
+	// If the node is the root, create a new root
+	if oldLeftNode.Parent == nil {
+		newRoot := NewBytesNode(false)
+		newRoot.Keys = append(newRoot.Keys, promotedKey)
+		newRoot.Children = append(newRoot.Children, oldLeftNode, newRightNode)
+		oldLeftNode.Parent = newRoot
+		newRightNode.Parent = newRoot
+		return newRoot
+	}
+
+	// Otherwise, insert into the oldParent
+	oldParent := oldLeftNode.Parent
+
+	i := slices.Index(oldParent.Children, oldLeftNode)
+	oldParent.Keys = slices.Insert(oldParent.Keys, i, promotedKey)
+	oldParent.Children = slices.Insert(oldParent.Children, i+1, newRightNode)
+
+	// Set the parent of the new node
+	newRightNode.Parent = oldParent
+
+	// If the parent has too many keys, split it
+	if len(oldParent.Keys) >= defaultOrder {
+		newParent, promotedKey := oldParent.SplitNode()
+		return insertBytesIntoParent(oldParent, newParent, promotedKey)
+	}
+
+	return nil // No new root, insert finished
+}
+
+// shortestSeparator returns the shortest key s that satisfies left < s <= right.
+// left must be less than right.
+//
+//	left = "user:0017", right = "user:0020"  =>  s = "user:002"
+func shortestSeparator(left, right []byte) []byte {
+	n := commonPrefixLen(left, right)
+	// left[n] < right[n] or left is a prefix of right, so right[:n+1] > left.
+	return slices.Clone(right[:n+1])
+}
+
+func commonPrefixLen(a, b []byte) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
@@ -0,0 +1,122 @@
+package bplustree
+
+import (
+	"bytes"
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// BytesTree is a B+Tree with variable-length byte-string keys, keys are compared by bytes.Compare.
+// It can be used for string keys or composite keys encoded as bytes.
+type BytesTree struct {
+	Root *BytesNode
+}
+
+func NewBytesTree() *BytesTree {
+	// Create a new leaf node as the root
+	root := NewBytesNode(true)
+	return &BytesTree{Root: root}
+}
+
+// findLeafNode finds the leaf node that would contain the given key
+// for insert node or search node
+func (t *BytesTree) findLeafNode(key []byte) *BytesNode {
+	node := t.Root
+
+	// Traverse down the tree until we reach a leaf node
+	for !node.IsLeaf {
+		// Find the right child to follow, separator <= key goes right.
+		i := slices.IndexFunc(node.Keys, func(k []byte) bool {
+			return bytes.Compare(key, k) < 0
+		})
+		if i < 0 {
+			i = len(node.Keys)
+		}
+
+		// Follow the child pointer
+		node = node.Children[i]
+	}
+
+	return node
+}
+
+func (t *BytesTree) Search(key []byte) (*BytesNode, error) {
+	leaf := t.findLeafNode(key)
+
+	if bytes.HasPrefix(key, leaf.Prefix) {
+		if _, found := leaf.search(key); found {
+			return leaf, nil // Key found
+		}
+	}
+
+	// Key not found
+	return nil, &KeyError{Op: "search", Key: slices.Clone(key), Err: ErrKeyNotFound}
+}
+
+// Insert inserts a copy of key into the tree.
+func (t *BytesTree) Insert(key []byte) error {
+	// Find the leaf node where the key should be inserted
+	leaf := t.findLeafNode(key)
+
+	// insert key, check if the key already exists
+	if !leaf.insertLeafKey(key) {
+		return &KeyError{Op: "insert", Key: slices.Clone(key), Err: ErrDuplicateKey}
+	}
+
+	// Handle the case where the leaf node is full
+	if len(leaf.Keys) >= defaultOrder {
+		newNode, pk := leaf.SplitNode()
+		newRoot := insertBytesIntoParent(leaf, newNode, pk)
+		if newRoot != nil {
+			t.Root = newRoot
+		}
+	}
+
+	return nil
+}
+
+// Ascend returns an iterator over all keys >= from in ascending order,
+// it follows the Next pointer of leaf nodes.
+func (t *BytesTree) Ascend(from []byte) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		for leaf := t.findLeafNode(from); leaf != nil; leaf = leaf.Next {
+			for i := range leaf.Keys {
+				key := leaf.Key(i)
+				if bytes.Compare(key, from) < 0 {
+					continue
+				}
+				if !yield(key) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// PrintBytesTree prints the tree structure for debugging
+func PrintBytesTree(node *BytesNode, level int) {
+	if node == nil {
+		return
+	}
+
+	indent := ""
+	for range level {
+		indent += "\t"
+	}
+
+	fmt.Printf("%sNode(", indent)
+	if node.IsLeaf {
+		fmt.Printf("Leaf): Prefix: %q, Keys: %q", node.Prefix, node.Keys)
+		if node.Next != nil {
+			fmt.Printf(", next: %q", node.Next.Key(0))
+		}
+		fmt.Println()
+	} else {
+		fmt.Printf("Internal): Keys: %q", node.Keys)
+		fmt.Println()
+		for _, child := range node.Children {
+			PrintBytesTree(child, level+1)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package bplustree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestBytesInsertFind(t *testing.T) {
+	tree := NewBytesTree()
+
+	keys := make([][]byte, 0, 200)
+	for _, i := range rand.Perm(200) {
+		keys = append(keys, fmt.Appendf(nil, "user:%04d", i))
+	}
+	keys = append(keys, []byte(""), []byte("user:"), []byte("a"), []byte("user:0001x"))
+
+	for _, k := range keys {
+		if err := tree.Insert(k); err != nil {
+			t.Fatal(string(k), err)
+		}
+	}
+
+	if err := tree.Insert([]byte("user:0042")); err == nil {
+		t.Error("insert duplicate key should fail")
+	}
+
+	for _, k := range keys {
+		if _, err := tree.Search(k); err != nil {
+			t.Errorf("key %q: %v", k, err)
+		}
+	}
+
+	for _, k := range []string{"user:0200", "user:00", "b", "user:0001y"} {
+		if _, err := tree.Search([]byte(k)); err == nil {
+			t.Errorf("key %q should not exist", k)
+		}
+	}
+
+	// leaf linked list must be sorted
+	leaf := tree.findLeafNode(nil)
+	var prev []byte
+	count := 0
+	for ; leaf != nil; leaf = leaf.Next {
+		for i := range leaf.Keys {
+			k := leaf.Key(i)
+			if count > 0 && bytes.Compare(prev, k) >= 0 {
+				t.Fatalf("keys out of order: %q >= %q", prev, k)
+			}
+			prev = k
+			count++
+		}
+	}
+	if count != len(keys) {
+		t.Errorf("got %d keys, want %d", count, len(keys))
+	}
+}
+
+func TestShortestSeparator(t *testing.T) {
+	tests := []struct {
+		left, right, want string
+	}{
+		{"user:0017", "user:0020", "user:002"},
+		{"abc", "abcd", "abcd"},
+		{"", "b", "b"},
+		{"apple", "banana", "b"},
+	}
+
+	for _, tt := range tests {
+		got := shortestSeparator([]byte(tt.left), []byte(tt.right))
+		if string(got) != tt.want {
+			t.Errorf("shortestSeparator(%q, %q) = %q, want %q", tt.left, tt.right, got, tt.want)
+		}
+	}
+}
+
+// 比较 int key 和 prefix compressed []byte key 的内存占用.
+//
+//	go test -run=^$ -bench=Memory -benchmem
+func BenchmarkMemoryInt(b *testing.B) {
+	const n = 100_000
+	for b.Loop() {
+		before := heapAlloc()
+		tree := NewBPlusTree()
+		for i := range n {
+			_ = tree.Insert(i)
+		}
+		b.ReportMetric(float64(heapAlloc()-before)/n, "heap-B/key")
+		runtime.KeepAlive(tree)
+	}
+}
+
+func BenchmarkMemoryBytes(b *testing.B) {
+	const n = 100_000
+	keys := make([][]byte, n)
+	for i := range keys {
+		keys[i] = fmt.Appendf(nil, "user:%08d", i)
+	}
+
+	for b.Loop() {
+		before := heapAlloc()
+		tree := NewBytesTree()
+		for _, k := range keys {
+			_ = tree.Insert(k)
+		}
+		b.ReportMetric(float64(heapAlloc()-before)/n, "heap-B/key")
+		runtime.KeepAlive(tree)
+	}
+}
+
+// heapAlloc returns the live heap after a GC, as int64 so the difference of two reads does not wrap around.
+func heapAlloc() int64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return int64(m.HeapAlloc)
+}
+
+func TestBytesDelete(t *testing.T) {
+	tree := NewBytesTree()
+
+	const n = 500
+	for _, i := range rand.Perm(n) {
+		if err := tree.Insert(fmt.Appendf(nil, "user:%04d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted := make(map[int]bool)
+	for _, i := range rand.Perm(n)[:n*3/4] {
+		if err := tree.Delete(fmt.Appendf(nil, "user:%04d", i)); err != nil {
+			t.Fatal(err)
+		}
+		deleted[i] = true
+		checkBytesTree(t, tree)
+	}
+	if err := tree.Delete([]byte("user:0000x")); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Delete(missing) = %v, want ErrKeyNotFound", err)
+	}
+
+	var want [][]byte
+	for i := range n {
+		key := fmt.Appendf(nil, "user:%04d", i)
+		if _, err := tree.Search(key); (err == nil) == deleted[i] {
+			t.Errorf("Search(%s) = %v, deleted %t", key, err, deleted[i])
+		}
+		if !deleted[i] {
+			want = append(want, key)
+		}
+	}
+	if got := slices.Collect(tree.Ascend(nil)); !slices.EqualFunc(got, want, bytes.Equal) {
+		t.Errorf("Ascend() = %q, want %q", got, want)
+	}
+
+	// delete the rest, the root becomes an empty leaf.
+	for _, key := range want {
+		if err := tree.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !tree.Root.IsLeaf || len(tree.Root.Keys) != 0 {
+		t.Errorf("root after deleting all keys: leaf %t, %d keys", tree.Root.IsLeaf, len(tree.Root.Keys))
+	}
+}
+
+// checkBytesTree checks that every node has enough keys, every key is within the separators of its parents,
+// and the parent pointers are correct.
+func checkBytesTree(t *testing.T, tree *BytesTree) {
+	t.Helper()
+	var check func(node *BytesNode, lo, hi []byte)
+	check = func(node *BytesNode, lo, hi []byte) {
+		if node != tree.Root && len(node.Keys) < bytesMinKeys {
+			t.Fatalf("node %q has too few keys", node.Keys)
+		}
+		if node.IsLeaf {
+			for i := range node.Keys {
+				k := node.Key(i)
+				if (lo != nil && bytes.Compare(k, lo) < 0) || (hi != nil && bytes.Compare(k, hi) >= 0) {
+					t.Fatalf("key %q is not in [%q, %q)", k, lo, hi)
+				}
+			}
+			return
+		}
+		for i, child := range node.Children {
+			if child.Parent != node {
+				t.Fatalf("child %q has wrong parent", child.Keys)
+			}
+			childLo, childHi := lo, hi
+			if i > 0 {
+				childLo = node.Keys[i-1]
+			}
+			if i < len(node.Keys) {
+				childHi = node.Keys[i]
+			}
+			check(child, childLo, childHi)
+		}
+	}
+	check(tree.Root, nil, nil)
+}
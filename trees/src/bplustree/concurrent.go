@@ -0,0 +1,491 @@
+package bplustree
+
+import (
+	"iter"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// ConcurrentTree is a B+Tree which is safe for concurrent use, it uses latch coupling (crabbing).
+//
+// 每个 node 有一个 sync.RWMutex (latch), 所有操作都从 root 向下获取 latch.
+//
+//   - Get / Range: 从上到下获取 shared latch, 拿到 child 的 latch 之后释放 parent 的 latch.
+//     Range 沿着 leaf 的 Next 指针 hand over hand 获取下一个 leaf 的 latch.
+//   - Put: 先乐观地用 shared latch 向下, 只对 leaf 获取 exclusive latch, leaf 不会 split 时直接插入.
+//     否则重新从 root 开始, 获取 exclusive latch, child 是 safe (插入后不会 split) 时释放所有 ancestor 的 latch.
+//   - Delete: 和 Put 相同, 先乐观地只对 leaf 获取 exclusive latch, leaf 不会 underflow 时直接删除.
+//     否则从 root 开始获取 exclusive latch, child 是 safe (删除后不会 underflow) 时释放所有 ancestor 的 latch,
+//     child 不 safe 时还要获取它的 sibling 的 exclusive latch, borrow/merge 都在持有 latch 时完成.
+//
+// latch 的顺序: internal node 从上到下, 最后是 leaf, 同一层的 leaf 从左到右 (和 Range 相同), 所以不会死锁.
+// ConcurrentTree 不使用 Parent 指针, split 和 merge 时使用仍然持有 latch 的 path 向上传播.
+type ConcurrentTree struct {
+	rootLatch sync.RWMutex // protects root pointer
+	root      *cnode
+	order     int
+	size      atomic.Int64
+}
+
+type cnode struct {
+	latch    sync.RWMutex
+	isLeaf   bool // never changes after the node is created
+	keys     []int
+	values   []any    // Only used for leaf nodes
+	children []*cnode // Only used for internal nodes
+	next     *cnode   // Only used for leaf nodes
+}
+
+// NewConcurrentTree creates a concurrent B+Tree of the given order, order must be at least 3.
+func NewConcurrentTree(order int) *ConcurrentTree {
+	if order < 3 {
+		panic("bplustree: order must be at least 3")
+	}
+	return &ConcurrentTree{root: &cnode{isLeaf: true}, order: order}
+}
+
+// childIndex returns the index of the child to follow, separator <= key goes right.
+func (node *cnode) childIndex(key int) int {
+	i, found := slices.BinarySearch(node.keys, key)
+	if found {
+		i++
+	}
+	return i
+}
+
+// latchRoot returns the root node with shared latch held.
+func (t *ConcurrentTree) latchRoot() *cnode {
+	t.rootLatch.RLock()
+	node := t.root
+	node.latch.RLock()
+	t.rootLatch.RUnlock()
+	return node
+}
+
+// findLeafShared returns the leaf node that would contain key, with shared latch held.
+func (t *ConcurrentTree) findLeafShared(key int) *cnode {
+	node := t.latchRoot()
+	for !node.isLeaf {
+		child := node.children[node.childIndex(key)]
+		child.latch.RLock()
+		node.latch.RUnlock()
+		node = child
+	}
+	return node
+}
+
+// Get returns the value of the given key, and whether the key is found.
+func (t *ConcurrentTree) Get(key int) (any, bool) {
+	leaf := t.findLeafShared(key)
+	defer leaf.latch.RUnlock()
+
+	if i, found := slices.BinarySearch(leaf.keys, key); found {
+		return leaf.values[i], true
+	}
+	return nil, false
+}
+
+// Len returns the number of keys, it may be stale when the tree is modified concurrently.
+func (t *ConcurrentTree) Len() int {
+	return int(t.size.Load())
+}
+
+// Put adds or replaces the value of key.
+func (t *ConcurrentTree) Put(key int, value any) {
+	if t.putOptimistic(key, value) {
+		return
+	}
+	t.putPessimistic(key, value)
+}
+
+// putOptimistic takes shared latches down to the leaf and exclusive latch on the leaf only.
+// returns false if the leaf would split, nothing is changed in this case.
+func (t *ConcurrentTree) putOptimistic(key int, value any) bool {
+	t.rootLatch.RLock()
+	node := t.root
+	if node.isLeaf {
+		node.latch.Lock()
+	} else {
+		node.latch.RLock()
+	}
+	t.rootLatch.RUnlock()
+
+	for !node.isLeaf {
+		child := node.children[node.childIndex(key)]
+		if child.isLeaf {
+			child.latch.Lock()
+		} else {
+			child.latch.RLock()
+		}
+		node.latch.RUnlock()
+		node = child
+	}
+	defer node.latch.Unlock()
+
+	i, found := slices.BinarySearch(node.keys, key)
+	if found {
+		node.values[i] = value
+		return true
+	}
+	if !t.safe(node) {
+		return false
+	}
+
+	node.keys = slices.Insert(node.keys, i, key)
+	node.values = slices.Insert(node.values, i, value)
+	t.size.Add(1)
+	return true
+}
+
+// safe reports whether inserting one key into node will not split it.
+func (t *ConcurrentTree) safe(node *cnode) bool {
+	return len(node.keys) < t.order-1
+}
+
+// putPessimistic takes exclusive latches from the root, ancestors are released once a child is safe.
+func (t *ConcurrentTree) putPessimistic(key int, value any) {
+	t.rootLatch.Lock()
+	rootHeld := true
+
+	node := t.root
+	node.latch.Lock()
+	path := []*cnode{node} // nodes with exclusive latch held, path[0] is safe or root
+
+	release := func() {
+		for _, n := range path {
+			n.latch.Unlock()
+		}
+		path = path[:0]
+		if rootHeld {
+			t.rootLatch.Unlock()
+			rootHeld = false
+		}
+	}
+	defer release()
+
+	if t.safe(node) {
+		// root will not split, the root pointer will not change.
+		t.rootLatch.Unlock()
+		rootHeld = false
+	}
+
+	for !node.isLeaf {
+		child := node.children[node.childIndex(key)]
+		child.latch.Lock()
+		if t.safe(child) {
+			release()
+		}
+		path = append(path, child)
+		node = child
+	}
+
+	i, found := slices.BinarySearch(node.keys, key)
+	if found {
+		node.values[i] = value
+		return
+	}
+	node.keys = slices.Insert(node.keys, i, key)
+	node.values = slices.Insert(node.values, i, value)
+	t.size.Add(1)
+
+	// propagate splits up along the latched path
+	for j := len(path) - 1; j >= 0 && len(path[j].keys) >= t.order; j-- {
+		right, promotedKey := path[j].split()
+		if j == 0 {
+			// path[0] is unsafe, so it is the root and rootLatch is still held.
+			t.root = &cnode{
+				keys:     []int{promotedKey},
+				children: []*cnode{path[j], right},
+			}
+			continue
+		}
+
+		parent := path[j-1]
+		idx := slices.Index(parent.children, path[j])
+		parent.keys = slices.Insert(parent.keys, idx, promotedKey)
+		parent.children = slices.Insert(parent.children, idx+1, right)
+	}
+}
+
+// split moves the right half of node to a new node, the exclusive latch of node must be held.
+// The new node is not reachable by other goroutines until it is linked into the parent or Next.
+func (node *cnode) split() (rightNode *cnode, promotedKey int) {
+	splitIndex := len(node.keys) / 2
+	rightNode = &cnode{isLeaf: node.isLeaf}
+
+	if node.isLeaf {
+		rightNode.keys = slices.Clone(node.keys[splitIndex:])
+		rightNode.values = slices.Clone(node.values[splitIndex:])
+		node.keys = slices.Clip(node.keys[:splitIndex])
+		clear(node.values[splitIndex:])
+		node.values = slices.Clip(node.values[:splitIndex])
+
+		rightNode.next = node.next
+		node.next = rightNode
+		return rightNode, rightNode.keys[0]
+	}
+
+	promotedKey = node.keys[splitIndex]
+	rightNode.keys = slices.Clone(node.keys[splitIndex+1:])
+	rightNode.children = slices.Clone(node.children[splitIndex+1:])
+	node.keys = slices.Clip(node.keys[:splitIndex])
+	clear(node.children[splitIndex+1:])
+	node.children = slices.Clip(node.children[:splitIndex+1])
+	return rightNode, promotedKey
+}
+
+// Delete removes key from the tree, returns false if the key is not found.
+func (t *ConcurrentTree) Delete(key int) bool {
+	if deleted, ok := t.deleteOptimistic(key); ok {
+		return deleted
+	}
+	return t.deletePessimistic(key)
+}
+
+// minKeys is the minimum number of keys of a non-root node, the same as BPlusTree.
+func (t *ConcurrentTree) minKeys() int {
+	return (t.order - 1) / 2
+}
+
+// deleteSafe reports whether removing one key from node will not underflow it.
+// The root never underflows, except an internal root with one key, which is replaced by its child.
+func (t *ConcurrentTree) deleteSafe(node *cnode, isRoot bool) bool {
+	if isRoot {
+		return node.isLeaf || len(node.keys) > 1
+	}
+	return len(node.keys) > t.minKeys()
+}
+
+// deleteOptimistic takes shared latches down to the leaf and exclusive latch on the leaf only.
+// ok is false if the leaf would underflow, nothing is changed in this case.
+func (t *ConcurrentTree) deleteOptimistic(key int) (deleted, ok bool) {
+	t.rootLatch.RLock()
+	node := t.root
+	isRoot := node.isLeaf
+	if node.isLeaf {
+		node.latch.Lock()
+	} else {
+		node.latch.RLock()
+	}
+	t.rootLatch.RUnlock()
+
+	for !node.isLeaf {
+		child := node.children[node.childIndex(key)]
+		if child.isLeaf {
+			child.latch.Lock()
+		} else {
+			child.latch.RLock()
+		}
+		node.latch.RUnlock()
+		node = child
+	}
+	defer node.latch.Unlock()
+
+	i, found := slices.BinarySearch(node.keys, key)
+	if !found {
+		return false, true
+	}
+	// a root leaf stays the root while its latch is held, it only changes by split.
+	if !t.deleteSafe(node, isRoot) {
+		return false, false
+	}
+
+	node.keys = slices.Delete(node.keys, i, i+1)
+	node.values = slices.Delete(node.values, i, i+1)
+	t.size.Add(-1)
+	return true, true
+}
+
+// deletePessimistic takes exclusive latches from the root, ancestors are released once a child is safe.
+// An unsafe child may borrow from or merge with a sibling, so its siblings are latched too.
+func (t *ConcurrentTree) deletePessimistic(key int) bool {
+	t.rootLatch.Lock()
+	rootHeld := true
+
+	node := t.root
+	node.latch.Lock()
+	path := []*cnode{node} // nodes with exclusive latch held, path[0] is safe or root
+	var siblings []*cnode  // latched siblings of path[1:]
+
+	release := func() {
+		for _, n := range path {
+			n.latch.Unlock()
+		}
+		for _, n := range siblings {
+			n.latch.Unlock()
+		}
+		path, siblings = path[:0], siblings[:0]
+		if rootHeld {
+			t.rootLatch.Unlock()
+			rootHeld = false
+		}
+	}
+	defer release()
+
+	if t.deleteSafe(node, true) {
+		// root will not be replaced, the root pointer will not change.
+		t.rootLatch.Unlock()
+		rootHeld = false
+	}
+
+	for !node.isLeaf {
+		idx := node.childIndex(key)
+		child := node.children[idx]
+		child.latch.Lock()
+		if t.deleteSafe(child, false) {
+			release()
+		} else {
+			// relatch child between its siblings, leaves must be latched from left to right.
+			// node is latched, so no writer can reach child while its latch is released.
+			child.latch.Unlock()
+			if idx > 0 {
+				node.children[idx-1].latch.Lock()
+				siblings = append(siblings, node.children[idx-1])
+			}
+			child.latch.Lock()
+			if idx < len(node.children)-1 {
+				node.children[idx+1].latch.Lock()
+				siblings = append(siblings, node.children[idx+1])
+			}
+		}
+		path = append(path, child)
+		node = child
+	}
+
+	i, found := slices.BinarySearch(node.keys, key)
+	if !found {
+		return false
+	}
+	node.keys = slices.Delete(node.keys, i, i+1)
+	node.values = slices.Delete(node.values, i, i+1)
+	t.size.Add(-1)
+
+	// propagate borrow/merge up along the latched path, path[0] is safe or root.
+	for j := len(path) - 1; j > 0 && len(path[j].keys) < t.minKeys(); j-- {
+		path[j-1].rebalanceChild(slices.Index(path[j-1].children, path[j]), t.minKeys())
+	}
+
+	// an empty internal root is replaced by its only child, rootLatch is still held in this case.
+	if root := path[0]; rootHeld && !root.isLeaf && len(root.keys) == 0 {
+		t.root = root.children[0]
+		root.children = nil
+	}
+	return true
+}
+
+// rebalanceChild fixes node.children[idx] which has less than minKeys keys, like BPlusTree.rebalance.
+// The exclusive latches of node, the child and its siblings must be held.
+func (node *cnode) rebalanceChild(idx, minKeys int) {
+	var left, right *cnode
+	if idx > 0 {
+		left = node.children[idx-1]
+	}
+	if idx < len(node.children)-1 {
+		right = node.children[idx+1]
+	}
+
+	switch child := node.children[idx]; {
+	case left != nil && len(left.keys) > minKeys:
+		node.borrowFromLeft(idx)
+	case right != nil && len(right.keys) > minKeys:
+		node.borrowFromRight(idx)
+	case left != nil:
+		node.mergeChildren(left, child, idx-1)
+	default:
+		node.mergeChildren(child, right, idx)
+	}
+}
+
+// borrowFromLeft moves the last key of children[idx-1] to children[idx].
+func (node *cnode) borrowFromLeft(idx int) {
+	child, left := node.children[idx], node.children[idx-1]
+	last := len(left.keys) - 1
+
+	if child.isLeaf {
+		child.keys = slices.Insert(child.keys, 0, left.keys[last])
+		child.values = slices.Insert(child.values, 0, left.values[last])
+		left.values[last] = nil
+		left.values = left.values[:last]
+		node.keys[idx-1] = child.keys[0]
+	} else {
+		// separator moves down to child, the last key of left moves up to node.
+		child.keys = slices.Insert(child.keys, 0, node.keys[idx-1])
+		child.children = slices.Insert(child.children, 0, left.children[last+1])
+		node.keys[idx-1] = left.keys[last]
+		left.children[last+1] = nil
+		left.children = left.children[:last+1]
+	}
+	left.keys = left.keys[:last]
+}
+
+// borrowFromRight moves the first key of children[idx+1] to children[idx].
+func (node *cnode) borrowFromRight(idx int) {
+	child, right := node.children[idx], node.children[idx+1]
+
+	if child.isLeaf {
+		child.keys = append(child.keys, right.keys[0])
+		child.values = append(child.values, right.values[0])
+		right.values = slices.Delete(right.values, 0, 1)
+		right.keys = slices.Delete(right.keys, 0, 1)
+		node.keys[idx] = right.keys[0]
+	} else {
+		// separator moves down to child, the first key of right moves up to node.
+		child.keys = append(child.keys, node.keys[idx])
+		child.children = append(child.children, right.children[0])
+		node.keys[idx] = right.keys[0]
+		right.keys = slices.Delete(right.keys, 0, 1)
+		right.children = slices.Delete(right.children, 0, 1)
+	}
+}
+
+// mergeChildren moves all keys of right into left, and removes the separator node.keys[sepIdx] and right from node.
+// right is unreachable afterwards: other goroutines reach it only through node or left.next, both are latched.
+func (node *cnode) mergeChildren(left, right *cnode, sepIdx int) {
+	if left.isLeaf {
+		left.keys = append(left.keys, right.keys...)
+		left.values = append(left.values, right.values...)
+		left.next = right.next
+	} else {
+		left.keys = append(left.keys, node.keys[sepIdx])
+		left.keys = append(left.keys, right.keys...)
+		left.children = append(left.children, right.children...)
+	}
+
+	// NOTE: disconnect right node, for GC purpose.
+	right.next, right.children, right.values = nil, nil, nil
+
+	node.keys = slices.Delete(node.keys, sepIdx, sepIdx+1)
+	node.children = slices.Delete(node.children, sepIdx+1, sepIdx+2)
+}
+
+// Range returns an iterator over keys in [lo, hi) in ascending order.
+//
+// Leaf nodes are latched hand over hand, a key that is in the tree during the whole iteration is always yielded.
+// The shared latch of the current leaf is held while yield is called,
+// so the loop body must not modify the tree, other goroutines can.
+func (t *ConcurrentTree) Range(lo, hi int) iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		leaf := t.findLeafShared(lo)
+		for {
+			for i, key := range leaf.keys {
+				if key < lo {
+					continue
+				}
+				if key >= hi || !yield(key, leaf.values[i]) {
+					leaf.latch.RUnlock()
+					return
+				}
+			}
+
+			next := leaf.next
+			if next == nil {
+				leaf.latch.RUnlock()
+				return
+			}
+			next.latch.RLock()
+			leaf.latch.RUnlock()
+			leaf = next
+		}
+	}
+}
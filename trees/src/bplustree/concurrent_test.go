@@ -0,0 +1,244 @@
+package bplustree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// 并发测试需要在 race detector 下运行:
+//
+//	go test -race -run Concurrent ./bplustree
+func TestConcurrentTree(t *testing.T) {
+	const (
+		writers = 8
+		readers = 4
+		perW    = 5000
+		initial = 1000 // keys [0, initial) exist before the scans start
+	)
+
+	tree := NewConcurrentTree(8)
+	for k := range initial {
+		tree.Put(k, k)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	// readers: every scan must be sorted and must see all the initial keys.
+	for range readers {
+		wg.Go(func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				prev, seen := -1, 0
+				for k, v := range tree.Range(0, initial+writers*perW) {
+					if k <= prev {
+						t.Errorf("range out of order: %d after %d", k, prev)
+						return
+					}
+					if v != k {
+						t.Errorf("key %d has value %v", k, v)
+						return
+					}
+					if k < initial {
+						seen++
+					}
+					prev = k
+				}
+				if seen != initial {
+					t.Errorf("range saw %d initial keys, want %d", seen, initial)
+					return
+				}
+			}
+		})
+	}
+
+	// writers insert disjoint keys in random order, and update the initial keys.
+	var writerWG sync.WaitGroup
+	for id := range writers {
+		writerWG.Go(func() {
+			base := initial + id*perW
+			for _, i := range rand.Perm(perW) {
+				tree.Put(base+i, base+i)
+				if k := rand.IntN(initial); i%10 == 0 {
+					tree.Put(k, k)
+				}
+			}
+		})
+	}
+	writerWG.Wait()
+	close(done)
+	wg.Wait()
+
+	total := initial + writers*perW
+	if tree.Len() != total {
+		t.Errorf("Len() = %d, want %d", tree.Len(), total)
+	}
+	for k := range total {
+		if v, ok := tree.Get(k); !ok || v != k {
+			t.Fatalf("Get(%d) = %v, %t", k, v, ok)
+		}
+	}
+
+	count := 0
+	for range tree.Range(-1, total+1) {
+		count++
+	}
+	if count != total {
+		t.Errorf("Range yields %d keys, want %d", count, total)
+	}
+}
+
+func TestConcurrentTreeDelete(t *testing.T) {
+	const (
+		writers = 8
+		readers = 4
+		perW    = 3000
+		initial = 1000 // keys [0, initial) are never deleted
+	)
+
+	// small order, so that borrow and merge happen at every level.
+	tree := NewConcurrentTree(4)
+	for k := range initial {
+		tree.Put(k, k)
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	for range readers {
+		wg.Go(func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				prev, seen := -1, 0
+				for k := range tree.Range(0, initial+writers*perW) {
+					if k <= prev {
+						t.Errorf("range out of order: %d after %d", k, prev)
+						return
+					}
+					if k < initial {
+						seen++
+					}
+					prev = k
+				}
+				if seen != initial {
+					t.Errorf("range saw %d initial keys, want %d", seen, initial)
+					return
+				}
+				if k := rand.IntN(initial); !has(tree, k) {
+					t.Errorf("Get(%d) not found", k)
+					return
+				}
+			}
+		})
+	}
+
+	// writers insert disjoint keys, then delete the odd ones in random order.
+	var writerWG sync.WaitGroup
+	for id := range writers {
+		writerWG.Go(func() {
+			base := initial + id*perW
+			for _, i := range rand.Perm(perW) {
+				tree.Put(base+i, base+i)
+			}
+			for _, i := range rand.Perm(perW) {
+				if i%2 == 1 && !tree.Delete(base+i) {
+					t.Errorf("Delete(%d) = false", base+i)
+				}
+				if tree.Delete(-1 - i) {
+					t.Errorf("Delete(%d) = true", -1-i)
+				}
+			}
+		})
+	}
+	writerWG.Wait()
+	close(done)
+	wg.Wait()
+
+	total := initial + writers*perW/2
+	if tree.Len() != total {
+		t.Errorf("Len() = %d, want %d", tree.Len(), total)
+	}
+	for k := range initial + writers*perW {
+		if want := k < initial || (k-initial)%2 == 0; has(tree, k) != want {
+			t.Fatalf("Get(%d) found = %t, want %t", k, !want, want)
+		}
+	}
+	if err := validateConcurrent(tree); err != nil {
+		t.Fatal(err)
+	}
+
+	// delete everything, the root shrinks back to a leaf.
+	for k := range initial + writers*perW {
+		tree.Delete(k)
+	}
+	if tree.Len() != 0 || !tree.root.isLeaf || len(tree.root.keys) != 0 {
+		t.Errorf("Len() = %d, root %v after deleting all keys", tree.Len(), tree.root.keys)
+	}
+}
+
+func has(tree *ConcurrentTree, key int) bool {
+	_, ok := tree.Get(key)
+	return ok
+}
+
+// validateConcurrent checks the key count of every node, the key order and the leaf depth,
+// the tree must not be modified concurrently.
+func validateConcurrent(t *ConcurrentTree) error {
+	leafDepth := -1
+	var prev *cnode
+	var validate func(node *cnode, depth int) error
+	validate = func(node *cnode, depth int) error {
+		if len(node.keys) >= t.order || (node != t.root && len(node.keys) < t.minKeys()) {
+			return fmt.Errorf("node %v has %d keys", node.keys, len(node.keys))
+		}
+		if !slices.IsSorted(node.keys) {
+			return fmt.Errorf("node %v keys are not sorted", node.keys)
+		}
+		if node.isLeaf {
+			if leafDepth >= 0 && depth != leafDepth {
+				return fmt.Errorf("leaf %v at depth %d, want %d", node.keys, depth, leafDepth)
+			}
+			leafDepth = depth
+			if prev != nil && prev.next != node {
+				return fmt.Errorf("leaf %v has wrong next pointer", prev.keys)
+			}
+			prev = node
+			return nil
+		}
+		if len(node.children) != len(node.keys)+1 {
+			return fmt.Errorf("node %v has %d children", node.keys, len(node.children))
+		}
+		for i, child := range node.children {
+			if i > 0 && len(child.keys) > 0 && child.keys[0] < node.keys[i-1] {
+				return fmt.Errorf("child %v is less than separator %d", child.keys, node.keys[i-1])
+			}
+			if i < len(node.keys) && len(child.keys) > 0 && child.keys[len(child.keys)-1] >= node.keys[i] {
+				return fmt.Errorf("child %v is not less than separator %d", child.keys, node.keys[i])
+			}
+			if err := validate(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := validate(t.root, 0); err != nil {
+		return err
+	}
+	if prev.next != nil {
+		return fmt.Errorf("last leaf %v has next pointer", prev.keys)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package bplustree
+
+import "slices"
+
+// Delete removes key from the tree, returns false if the key is not found.
+//
+// 删除之后如果 node 的 key 少于 minKeys, 先尝试从相邻的 sibling 借一个 key,
+// sibling 也没有多余的 key 时和 sibling 合并, 合并会删除 parent 中的一个 key, 所以需要向上继续 rebalance.
+//
+//	borrow from left:          merge with left:
+//	     [5]          [4]          [5]          [ ]
+//	   /    \   =>   /   \        /   \   =>     |
+//	[3 4]   [ ]    [3]   [4]    [3]   [ ]      [3]
+func (t *BPlusTree) Delete(key int) bool {
+	leaf := t.findLeafNode(key)
+
+	i, found := slices.BinarySearch(leaf.Keys, key)
+	if !found {
+		return false
+	}
+
+	leaf.Keys = slices.Delete(leaf.Keys, i, i+1)
+	leaf.Values = slices.Delete(leaf.Values, i, i+1)
+	t.size--
+
+	t.rebalance(leaf)
+	return true
+}
+
+// minKeys is the minimum number of keys of a non-root node.
+// 非叶子节点的子节点个数范围是 [ceil(order/2), order], 所以 key 的个数至少是 ceil(order/2)-1.
+func (t *BPlusTree) minKeys() int {
+	return (t.order - 1) / 2
+}
+
+// rebalance fixes the node after a key is removed from it.
+func (t *BPlusTree) rebalance(node *Node) {
+	if node.Parent == nil {
+		// root node can have less keys, an empty internal root is replaced by its only child.
+		if !node.IsLeaf && len(node.Keys) == 0 {
+			t.Root = node.Children[0]
+			t.Root.Parent = nil
+			node.Children = nil
+		}
+		return
+	}
+
+	if len(node.Keys) >= t.minKeys() {
+		return
+	}
+
+	parent := node.Parent
+	idx := slices.Index(parent.Children, node)
+
+	var left, right *Node
+	if idx > 0 {
+		left = parent.Children[idx-1]
+	}
+	if idx < len(parent.Children)-1 {
+		right = parent.Children[idx+1]
+	}
+
+	switch {
+	case left != nil && len(left.Keys) > t.minKeys():
+		borrowFromLeft(node, left, idx)
+	case right != nil && len(right.Keys) > t.minKeys():
+		borrowFromRight(node, right, idx)
+	case left != nil:
+		mergeNodes(left, node, idx-1)
+		t.rebalance(parent)
+	default:
+		mergeNodes(node, right, idx)
+		t.rebalance(parent)
+	}
+}
+
+// borrowFromLeft moves the last key of left sibling to node, idx is the index of node in its parent.
+func borrowFromLeft(node, left *Node, idx int) {
+	parent := node.Parent
+	last := len(left.Keys) - 1
+
+	if node.IsLeaf {
+		node.Keys = slices.Insert(node.Keys, 0, left.Keys[last])
+		node.Values = slices.Insert(node.Values, 0, left.Values[last])
+		left.Values[last] = nil
+		left.Values = left.Values[:last]
+		parent.Keys[idx-1] = node.Keys[0]
+	} else {
+		// separator moves down to node, the last key of left moves up to parent.
+		child := left.Children[last+1]
+		node.Keys = slices.Insert(node.Keys, 0, parent.Keys[idx-1])
+		node.Children = slices.Insert(node.Children, 0, child)
+		child.Parent = node
+		parent.Keys[idx-1] = left.Keys[last]
+		left.Children[last+1] = nil
+		left.Children = left.Children[:last+1]
+	}
+	left.Keys = left.Keys[:last]
+}
+
+// borrowFromRight moves the first key of right sibling to node, idx is the index of node in its parent.
+func borrowFromRight(node, right *Node, idx int) {
+	parent := node.Parent
+
+	if node.IsLeaf {
+		node.Keys = append(node.Keys, right.Keys[0])
+		node.Values = append(node.Values, right.Values[0])
+		right.Values = slices.Delete(right.Values, 0, 1)
+		right.Keys = slices.Delete(right.Keys, 0, 1)
+		parent.Keys[idx] = right.Keys[0]
+	} else {
+		// separator moves down to node, the first key of right moves up to parent.
+		child := right.Children[0]
+		node.Keys = append(node.Keys, parent.Keys[idx])
+		node.Children = append(node.Children, child)
+		child.Parent = node
+		parent.Keys[idx] = right.Keys[0]
+		right.Keys = slices.Delete(right.Keys, 0, 1)
+		right.Children = slices.Delete(right.Children, 0, 1)
+	}
+}
+
+// mergeNodes moves all keys of right into left, and removes the separator parent.Keys[sepIdx] and right from parent.
+func mergeNodes(left, right *Node, sepIdx int) {
+	parent := left.Parent
+
+	if left.IsLeaf {
+		left.Keys = append(left.Keys, right.Keys...)
+		left.Values = append(left.Values, right.Values...)
+		left.Next = right.Next
+	} else {
+		left.Keys = append(left.Keys, parent.Keys[sepIdx])
+		left.Keys = append(left.Keys, right.Keys...)
+		for _, child := range right.Children {
+			child.Parent = left
+		}
+		left.Children = append(left.Children, right.Children...)
+	}
+
+	// NOTE: disconnect right node, for GC purpose.
+	right.Parent, right.Next, right.Children, right.Values = nil, nil, nil, nil
+
+	parent.Keys = slices.Delete(parent.Keys, sepIdx, sepIdx+1)
+	parent.Children = slices.Delete(parent.Children, sepIdx+1, sepIdx+2)
+}
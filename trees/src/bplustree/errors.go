@@ -0,0 +1,33 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrKeyNotFound  = errors.New("key not found")
+	ErrDuplicateKey = errors.New("duplicate key")
+	ErrCorrupt      = errors.New("tree is corrupt") // returned by Validate
+)
+
+// KeyError records an error and the operation and key that caused it.
+//
+//	errors.Is(err, ErrKeyNotFound)
+//	var ke *KeyError; errors.As(err, &ke)
+type KeyError struct {
+	Op  string // "search", "insert"
+	Key any    // int or []byte
+	Err error
+}
+
+func (e *KeyError) Error() string {
+	if k, ok := e.Key.([]byte); ok {
+		return fmt.Sprintf("bplustree: %s %q: %v", e.Op, k, e.Err)
+	}
+	return fmt.Sprintf("bplustree: %s %v: %v", e.Op, e.Key, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
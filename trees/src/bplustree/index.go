@@ -0,0 +1,219 @@
+package bplustree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// Index maintains one primary B+Tree and several secondary B+Trees over rows of type T.
+//
+//	primary:   BPlusTree, primary key -> row, primary key 必须唯一.
+//	secondary: MultiTree, secondary key + row ID -> row, secondary key 可以重复.
+//
+// Index keys are extracted from the row by the functions given to NewIndex and AddSecondary.
+type Index[T any] struct {
+	primaryKey func(T) int
+	primary    *BPlusTree // primary key -> row
+	secondary  map[string]*secondaryIndex[T]
+}
+
+type secondaryIndex[T any] struct {
+	key  func(T) []byte
+	tree *MultiTree
+}
+
+func NewIndex[T any](primaryKey func(T) int) *Index[T] {
+	return &Index[T]{
+		primaryKey: primaryKey,
+		primary:    NewBPlusTree(),
+		secondary:  make(map[string]*secondaryIndex[T]),
+	}
+}
+
+// AddSecondary adds a secondary index, existing rows are indexed immediately.
+func (idx *Index[T]) AddSecondary(name string, key func(T) []byte) error {
+	if _, ok := idx.secondary[name]; ok {
+		return fmt.Errorf("bplustree: secondary index %q already exists", name)
+	}
+
+	s := &secondaryIndex[T]{key: key, tree: NewMultiTree()}
+	for id, row := range idx.primary.All() {
+		if err := s.tree.Insert(key(row.(T)), rowID(id)); err != nil {
+			return err
+		}
+	}
+
+	idx.secondary[name] = s
+	return nil
+}
+
+// Insert adds row to the primary index and all secondary indexes.
+// returns ErrDuplicateKey if the primary key or a (secondary key, row ID) pair already exists,
+// all keys are checked before any index is modified, so a failed Insert leaves the indexes unchanged.
+func (idx *Index[T]) Insert(row T) error {
+	id := idx.primaryKey(row)
+	if _, ok := idx.primary.Get(id); ok {
+		return &KeyError{Op: "insert", Key: id, Err: ErrDuplicateKey}
+	}
+
+	keys := make(map[*secondaryIndex[T]][]byte, len(idx.secondary))
+	for _, s := range idx.secondary {
+		key := s.key(row)
+		if s.tree.contains(key, rowID(id)) {
+			return &KeyError{Op: "insert", Key: slices.Clone(key), Err: ErrDuplicateKey}
+		}
+		keys[s] = key
+	}
+
+	idx.primary.insert(id, row, false)
+	var inserted []*secondaryIndex[T]
+	for s, key := range keys {
+		if err := s.tree.Insert(key, rowID(id)); err != nil {
+			// checked above, roll back anyway so that the indexes stay consistent.
+			for _, s := range inserted {
+				err = errors.Join(err, s.tree.Delete(keys[s], rowID(id)))
+			}
+			idx.primary.Delete(id)
+			return err
+		}
+		inserted = append(inserted, s)
+	}
+	return nil
+}
+
+// Delete removes the row of the given primary key from the primary index and all secondary indexes.
+// returns ErrKeyNotFound if the primary key does not exist,
+// ErrCorrupt if a secondary index has no entry for the row, nothing is removed in both cases.
+func (idx *Index[T]) Delete(id int) error {
+	old, ok := idx.primary.Get(id)
+	if !ok {
+		return &KeyError{Op: "delete", Key: id, Err: ErrKeyNotFound}
+	}
+
+	keys := make(map[*secondaryIndex[T]][]byte, len(idx.secondary))
+	for name, s := range idx.secondary {
+		key := s.key(old.(T))
+		if !s.tree.contains(key, rowID(id)) {
+			return fmt.Errorf("%w: secondary index %q has no entry for row %d", ErrCorrupt, name, id)
+		}
+		keys[s] = key
+	}
+
+	var err error
+	for s, key := range keys {
+		err = errors.Join(err, s.tree.Delete(key, rowID(id)))
+	}
+	idx.primary.Delete(id)
+	return err
+}
+
+// Update replaces the row with the same primary key, secondary entries whose key changed are moved.
+// returns ErrKeyNotFound if the primary key does not exist, all keys are checked before any index is modified.
+func (idx *Index[T]) Update(row T) error {
+	id := idx.primaryKey(row)
+	old, ok := idx.primary.Get(id)
+	if !ok {
+		return &KeyError{Op: "update", Key: id, Err: ErrKeyNotFound}
+	}
+
+	type move struct{ from, to []byte }
+	moves := make(map[*secondaryIndex[T]]move, len(idx.secondary))
+	for name, s := range idx.secondary {
+		m := move{from: s.key(old.(T)), to: s.key(row)}
+		if bytes.Equal(m.from, m.to) {
+			continue
+		}
+		if !s.tree.contains(m.from, rowID(id)) {
+			return fmt.Errorf("%w: secondary index %q has no entry for row %d", ErrCorrupt, name, id)
+		}
+		if s.tree.contains(m.to, rowID(id)) {
+			return &KeyError{Op: "update", Key: slices.Clone(m.to), Err: ErrDuplicateKey}
+		}
+		moves[s] = m
+	}
+
+	var err error
+	for s, m := range moves {
+		err = errors.Join(err, s.tree.Delete(m.from, rowID(id)), s.tree.Insert(m.to, rowID(id)))
+	}
+	idx.primary.insert(id, row, true)
+	return err
+}
+
+// Get returns the row of the given primary key.
+func (idx *Index[T]) Get(id int) (T, bool) {
+	row, ok := idx.primary.Get(id)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return row.(T), true
+}
+
+// Lookup returns an iterator over rows whose secondary key equals key, ordered by primary key.
+func (idx *Index[T]) Lookup(name string, key []byte) (iter.Seq[T], error) {
+	s, ok := idx.secondary[name]
+	if !ok {
+		return nil, fmt.Errorf("bplustree: secondary index %q not found", name)
+	}
+
+	return func(yield func(T) bool) {
+		for id := range s.tree.Lookup(key) {
+			if !yield(idx.row(primaryKey(id))) {
+				return
+			}
+		}
+	}, nil
+}
+
+// Range returns an iterator over rows whose secondary key is in [lo, hi), ordered by secondary key.
+// A nil hi means no upper bound.
+func (idx *Index[T]) Range(name string, lo, hi []byte) (iter.Seq[T], error) {
+	s, ok := idx.secondary[name]
+	if !ok {
+		return nil, fmt.Errorf("bplustree: secondary index %q not found", name)
+	}
+
+	return func(yield func(T) bool) {
+		for _, id := range s.tree.Range(lo, hi) {
+			if !yield(idx.row(primaryKey(id))) {
+				return
+			}
+		}
+	}, nil
+}
+
+// All returns an iterator over all rows ordered by primary key, it follows the leaf nodes of the primary tree.
+func (idx *Index[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for _, row := range idx.primary.All() {
+			if !yield(row.(T)) {
+				return
+			}
+		}
+	}
+}
+
+func (idx *Index[T]) row(id int) T {
+	row, _ := idx.primary.Get(id)
+	return row.(T)
+}
+
+// EncodeInt encodes v as an 8 bytes secondary key, the encoded keys keep the order of v.
+func EncodeInt(v int) []byte {
+	return binary.BigEndian.AppendUint64(nil, rowID(v))
+}
+
+// rowID converts a primary key to the row ID used as tiebreaker,
+// flip the sign bit so that negative keys are ordered before positive keys.
+func rowID(id int) uint64 {
+	return uint64(id) ^ 1<<63 //nolint:gosec // two's complement conversion is intended.
+}
+
+func primaryKey(rowID uint64) int {
+	return int(rowID ^ 1<<63) //nolint:gosec // two's complement conversion is intended.
+}
@@ -0,0 +1,213 @@
+package bplustree
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+type user struct {
+	ID   int
+	Name string
+	City string
+	Age  int
+}
+
+func TestMultiTree(t *testing.T) {
+	tree := NewMultiTree()
+
+	keys := []string{"b", "a", "a\x00", "ab", "a", "b", "a"}
+	for i, k := range keys {
+		if err := tree.Insert([]byte(k), uint64(len(keys)-i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Insert([]byte("a"), 6); err == nil {
+		t.Error("insert duplicate (key, rowID) pair should fail")
+	}
+
+	got := slices.Collect(tree.Lookup([]byte("a")))
+	if want := []uint64{1, 3, 6}; !slices.Equal(got, want) {
+		t.Errorf("Lookup(a) = %v, want %v", got, want)
+	}
+
+	var rangeKeys []string
+	for k := range tree.Range([]byte("a\x00"), []byte("b")) {
+		rangeKeys = append(rangeKeys, string(k))
+	}
+	if want := []string{"a\x00", "ab"}; !slices.Equal(rangeKeys, want) {
+		t.Errorf("Range = %q, want %q", rangeKeys, want)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	idx := NewIndex(func(u user) int { return u.ID })
+	err := idx.AddSecondary("city", func(u user) []byte { return []byte(u.City) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cities := []string{"Beijing", "Shanghai", "Shenzhen"}
+	for i := range 30 {
+		u := user{ID: 30 - i, Name: fmt.Sprintf("user%d", 30-i), City: cities[i%3], Age: 20 + i%7}
+		if err := idx.Insert(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := idx.Insert(user{ID: 1}); err == nil {
+		t.Error("insert duplicate primary key should fail")
+	}
+
+	// secondary index added after rows were inserted.
+	err = idx.AddSecondary("age", func(u user) []byte { return EncodeInt(u.Age) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := idx.Lookup("city", []byte("Shanghai"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for u := range rows {
+		if u.City != "Shanghai" {
+			t.Errorf("got city %s", u.City)
+		}
+		ids = append(ids, u.ID)
+	}
+	if len(ids) != 10 || !slices.IsSorted(ids) {
+		t.Errorf("Lookup(city, Shanghai) ids = %v", ids)
+	}
+
+	rows, err = idx.Range("age", EncodeInt(21), EncodeInt(23))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ages []int
+	for u := range rows {
+		ages = append(ages, u.Age)
+	}
+	if len(ages) == 0 || !slices.IsSorted(ages) || ages[0] != 21 || ages[len(ages)-1] != 22 {
+		t.Errorf("Range(age, 21, 23) ages = %v", ages)
+	}
+
+	if _, err := idx.Lookup("name", nil); err == nil {
+		t.Error("lookup unknown index should fail")
+	}
+
+	var all []int
+	for u := range idx.All() {
+		all = append(all, u.ID)
+	}
+	if len(all) != 30 || !slices.IsSorted(all) {
+		t.Errorf("All() ids = %v", all)
+	}
+}
+
+func TestIndexInsertAtomic(t *testing.T) {
+	idx := NewIndex(func(u user) int { return u.ID })
+	for _, name := range []string{"city", "name"} {
+		err := idx.AddSecondary(name, func(u user) []byte {
+			if name == "city" {
+				return []byte(u.City)
+			}
+			return []byte(u.Name)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a stale (key, row ID) pair in one secondary index makes the insert fail.
+	u := user{ID: 7, Name: "user7", City: "Beijing"}
+	if err := idx.secondary["name"].tree.Insert([]byte(u.Name), rowID(u.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Insert(u); !errors.Is(err, ErrDuplicateKey) {
+		t.Fatalf("Insert() err = %v, want ErrDuplicateKey", err)
+	}
+	if _, ok := idx.Get(u.ID); ok {
+		t.Error("primary index changed by failed Insert")
+	}
+	if ids := slices.Collect(idx.secondary["city"].tree.Lookup([]byte(u.City))); len(ids) != 0 {
+		t.Errorf("city index changed by failed Insert: %v", ids)
+	}
+}
+
+func TestIndexDeleteUpdate(t *testing.T) {
+	idx := NewIndex(func(u user) int { return u.ID })
+	if err := idx.AddSecondary("city", func(u user) []byte { return []byte(u.City) }); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.AddSecondary("age", func(u user) []byte { return EncodeInt(u.Age) }); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 20 {
+		if err := idx.Insert(user{ID: i, City: []string{"Beijing", "Shanghai"}[i%2], Age: 20 + i%5}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lookup := func(name string, key []byte) []int {
+		rows, err := idx.Lookup(name, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for u := range rows {
+			ids = append(ids, u.ID)
+		}
+		return ids
+	}
+
+	// Update moves the secondary entries whose key changed.
+	if err := idx.Update(user{ID: 4, City: "Shenzhen", Age: 24}); err != nil {
+		t.Fatal(err)
+	}
+	if got := lookup("city", []byte("Shenzhen")); !slices.Equal(got, []int{4}) {
+		t.Errorf("Lookup(city, Shenzhen) = %v", got)
+	}
+	if got := lookup("city", []byte("Beijing")); slices.Contains(got, 4) || len(got) != 9 {
+		t.Errorf("Lookup(city, Beijing) = %v", got)
+	}
+	if got := lookup("age", EncodeInt(24)); !slices.Equal(got, []int{4, 9, 14, 19}) {
+		t.Errorf("Lookup(age, 24) = %v", got)
+	}
+	if u, _ := idx.Get(4); u.City != "Shenzhen" {
+		t.Errorf("Get(4) = %+v", u)
+	}
+	if err := idx.Update(user{ID: 100}); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Update(missing) = %v, want ErrKeyNotFound", err)
+	}
+
+	// Delete removes the row from every index.
+	for _, id := range []int{4, 6} {
+		if err := idx.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := idx.Get(4); ok {
+		t.Error("Get(4) after Delete")
+	}
+	if got := lookup("city", []byte("Shenzhen")); len(got) != 0 {
+		t.Errorf("Lookup(city, Shenzhen) after Delete = %v", got)
+	}
+	if got := lookup("age", EncodeInt(21)); !slices.Equal(got, []int{1, 11, 16}) {
+		t.Errorf("Lookup(age, 21) after Delete = %v", got)
+	}
+	if err := idx.Delete(4); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Delete(4) twice = %v, want ErrKeyNotFound", err)
+	}
+
+	// a missing secondary entry is reported, the row is kept.
+	if err := idx.secondary["age"].tree.Delete(EncodeInt(22), rowID(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Delete(2); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Delete(2) = %v, want ErrCorrupt", err)
+	}
+	if got := lookup("city", []byte("Beijing")); !slices.Contains(got, 2) {
+		t.Errorf("Lookup(city, Beijing) after failed Delete = %v", got)
+	}
+}
@@ -0,0 +1,115 @@
+package bplustree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"iter"
+	"slices"
+)
+
+// MultiTree is a non-unique B+Tree, the same key can be inserted many times with different row IDs.
+//
+// row ID 作为 tiebreaker 和 key 一起编码成唯一的 composite key 存储在 BytesTree 中:
+//
+//	escape(key) + 0x00 0x01 + bigEndian(rowID)
+//
+// key 中的 0x00 被转义为 0x00 0xFF, 所以 composite key 的顺序和 (key, rowID) 的顺序一致,
+// 而且一个 key 编码后不会是另一个 key 编码后的前缀, eg: "a" 和 "ab".
+type MultiTree struct {
+	tree *BytesTree
+}
+
+func NewMultiTree() *MultiTree {
+	return &MultiTree{tree: NewBytesTree()}
+}
+
+// Insert adds the (key, rowID) pair, returns ErrDuplicateKey only if the same pair already exists.
+func (t *MultiTree) Insert(key []byte, rowID uint64) error {
+	if err := t.tree.Insert(compositeKey(key, rowID)); err != nil {
+		return &KeyError{Op: "insert", Key: slices.Clone(key), Err: ErrDuplicateKey}
+	}
+	return nil
+}
+
+// Delete removes the (key, rowID) pair, returns ErrKeyNotFound if the pair does not exist.
+func (t *MultiTree) Delete(key []byte, rowID uint64) error {
+	if err := t.tree.Delete(compositeKey(key, rowID)); err != nil {
+		return &KeyError{Op: "delete", Key: slices.Clone(key), Err: ErrKeyNotFound}
+	}
+	return nil
+}
+
+// contains reports whether the (key, rowID) pair exists.
+func (t *MultiTree) contains(key []byte, rowID uint64) bool {
+	_, err := t.tree.Search(compositeKey(key, rowID))
+	return err == nil
+}
+
+// Lookup returns an iterator over row IDs of the given key, in ascending order.
+func (t *MultiTree) Lookup(key []byte) iter.Seq[uint64] {
+	prefix := escapeKey(nil, key)
+	return func(yield func(uint64) bool) {
+		for k := range t.tree.Ascend(prefix) {
+			if !bytes.HasPrefix(k, prefix) {
+				return
+			}
+			if !yield(binary.BigEndian.Uint64(k[len(prefix):])) {
+				return
+			}
+		}
+	}
+}
+
+// Range returns an iterator over (key, rowID) pairs with lo <= key < hi, ordered by key then rowID.
+// A nil hi means no upper bound.
+func (t *MultiTree) Range(lo, hi []byte) iter.Seq2[[]byte, uint64] {
+	from := escapeKey(nil, lo)
+	var to []byte
+	if hi != nil {
+		to = escapeKey(nil, hi)
+	}
+
+	return func(yield func([]byte, uint64) bool) {
+		for k := range t.tree.Ascend(from) {
+			if to != nil && bytes.Compare(k, to) >= 0 {
+				return
+			}
+			key, rowID := splitCompositeKey(k)
+			if !yield(key, rowID) {
+				return
+			}
+		}
+	}
+}
+
+func compositeKey(key []byte, rowID uint64) []byte {
+	dst := make([]byte, 0, len(key)+2+8)
+	dst = escapeKey(dst, key)
+	return binary.BigEndian.AppendUint64(dst, rowID)
+}
+
+func splitCompositeKey(k []byte) (key []byte, rowID uint64) {
+	escaped, id := k[:len(k)-8], k[len(k)-8:]
+	escaped = escaped[:len(escaped)-2] // trim terminator 0x00 0x01
+
+	key = make([]byte, 0, len(escaped))
+	for i := 0; i < len(escaped); i++ {
+		key = append(key, escaped[i])
+		if escaped[i] == 0x00 {
+			i++ // skip 0xFF
+		}
+	}
+	return key, binary.BigEndian.Uint64(id)
+}
+
+// escapeKey appends the escaped key and the terminator to dst.
+func escapeKey(dst, key []byte) []byte {
+	for _, b := range key {
+		if b == 0x00 {
+			dst = append(dst, 0x00, 0xFF)
+		} else {
+			dst = append(dst, b)
+		}
+	}
+	return append(dst, 0x00, 0x01)
+}
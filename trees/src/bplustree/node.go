@@ -12,20 +12,32 @@ import (
 type Node struct {
 	IsLeaf   bool
 	Keys     []int
+	Values   []any   // Only used for leaf nodes, Values[i] is the value of Keys[i]
 	Children []*Node // Only used for internal nodes
 	Next     *Node   // Only used for leaf nodes (for range queries)
 	Parent   *Node   // Parent reference
+	order    int     // order of the tree which the node belongs to
 }
 
-// NewNode creates a new node
+// NewNode creates a new node of the default order
 func NewNode(isLeaf bool) *Node {
-	return &Node{
-		IsLeaf:   isLeaf,
-		Keys:     make([]int, 0, order), // 多一个位置为了 split
-		Children: make([]*Node, 0, order+1),
-		Next:     nil,
-		Parent:   nil,
+	return newNode(isLeaf, defaultOrder)
+}
+
+func newNode(isLeaf bool, order int) *Node {
+	node := &Node{
+		IsLeaf: isLeaf,
+		Keys:   make([]int, 0, order), // 多一个位置为了 split
+		Next:   nil,
+		Parent: nil,
+		order:  order,
+	}
+	if isLeaf {
+		node.Values = make([]any, 0, order)
+	} else {
+		node.Children = make([]*Node, 0, order+1)
 	}
+	return node
 }
 
 func (node *Node) SplitNode() (newRightNode *Node, promotedKey int) {
@@ -39,16 +51,19 @@ func (node *Node) SplitNode() (newRightNode *Node, promotedKey int) {
 func splitLeafNode(node *Node) (newRightNode *Node, promotedKey int) {
 	// Create a new leaf node
 	// NOTE: 这里不设置 Parent, 因为 node 可能是 root 节点, 没有 Parent. Parent 设置放在后面.
-	rightNode := NewNode(true)
+	rightNode := newNode(true, node.order)
 
 	// Calculate split point - middle of the node
 	splitIndex := len(node.Keys) / 2
 
 	// Move half of the keys and values to the new node
 	rightNode.Keys = append(rightNode.Keys, node.Keys[splitIndex:]...)
+	rightNode.Values = append(rightNode.Values, node.Values[splitIndex:]...)
 
 	// Update the original node's keys and values
 	node.Keys = node.Keys[:splitIndex]
+	clear(node.Values[splitIndex:]) // NOTE: delete underlying value from ref, for GC purpose.
+	node.Values = node.Values[:splitIndex]
 
 	// Handle the linked list of leaf nodes for range queries
 	rightNode.Next = node.Next
@@ -64,7 +79,7 @@ func splitLeafNode(node *Node) (newRightNode *Node, promotedKey int) {
 func splitInternalNode(node *Node) (newRightNode *Node, promotedKey int) {
 	// Create a new internal node
 	// NOTE: 这里不设置 Parent, 因为 node 可能是 root 节点, 没有 Parent. Parent 设置放在后面.
-	rightNode := NewNode(false)
+	rightNode := newNode(false, node.order)
 
 	// Calculate split point - middle of the node
 	splitIndex := len(node.Keys) / 2
@@ -97,14 +112,15 @@ func splitInternalNode(node *Node) (newRightNode *Node, promotedKey int) {
 }
 
 func (node *Node) InsertIntoParent(newRightNode *Node, insertKey int) (newRoot *Node) {
-	return insertIntoParent(node, newRightNode, insertKey)
+	return insertIntoParent(node, newRightNode, insertKey, nil)
 }
 
 // InsertIntoParent inserts a key and node into the parent node
-func insertIntoParent(oldLeftNode, newRightNode *Node, promotedKey int) (newRoot *Node) {
+// counters counts the internal node splits, it can be nil.
+func insertIntoParent(oldLeftNode, newRightNode *Node, promotedKey int, counters *Counters) (newRoot *Node) {
 	// If the node is the root, create a new root
 	if oldLeftNode.Parent == nil {
-		newRoot := NewNode(false)
+		newRoot := newNode(false, oldLeftNode.order)
 		newRoot.Keys = append(newRoot.Keys, promotedKey)
 		newRoot.Children = append(newRoot.Children, oldLeftNode, newRightNode)
 		oldLeftNode.Parent = newRoot
@@ -130,9 +146,12 @@ func insertIntoParent(oldLeftNode, newRightNode *Node, promotedKey int) (newRoot
 	newRightNode.Parent = oldParent
 
 	// If the parent has too many keys, split it
-	if len(oldParent.Keys) >= order {
+	if len(oldParent.Keys) >= oldParent.order {
 		newParent, promotedKey := oldParent.SplitNode()
-		return oldParent.InsertIntoParent(newParent, promotedKey)
+		if counters != nil {
+			counters.InternalSplits++
+		}
+		return insertIntoParent(oldParent, newParent, promotedKey, counters)
 	}
 
 	return nil // No new root, insert finished
@@ -0,0 +1,83 @@
+package bplustree
+
+import (
+	"iter"
+	"math"
+	"slices"
+)
+
+// BPlusTree implements orderedmap.OrderedMap[int, any].
+
+// Get returns the value of the given key, and whether the key is found.
+func (t *BPlusTree) Get(key int) (any, bool) {
+	leaf := t.findLeafNode(key)
+	if i, found := slices.BinarySearch(leaf.Keys, key); found {
+		return leaf.Values[i], true
+	}
+	return nil, false
+}
+
+// Put adds or replaces the value of key.
+func (t *BPlusTree) Put(key int, value any) {
+	t.insert(key, value, true)
+}
+
+// Len returns the number of keys in the tree.
+func (t *BPlusTree) Len() int {
+	return t.size
+}
+
+// Min returns the smallest key and its value, returns false if the tree is empty.
+func (t *BPlusTree) Min() (int, any, bool) {
+	node := t.Root
+	for !node.IsLeaf {
+		node = node.Children[0]
+	}
+	if len(node.Keys) == 0 {
+		return 0, nil, false
+	}
+	return node.Keys[0], node.Values[0], true
+}
+
+// Max returns the largest key and its value, returns false if the tree is empty.
+func (t *BPlusTree) Max() (int, any, bool) {
+	node := t.Root
+	for !node.IsLeaf {
+		node = node.Children[len(node.Children)-1]
+	}
+	if len(node.Keys) == 0 {
+		return 0, nil, false
+	}
+	last := len(node.Keys) - 1
+	return node.Keys[last], node.Values[last], true
+}
+
+// Range returns an iterator over keys in [lo, hi) in ascending order,
+// it follows the Next pointer of leaf nodes.
+func (t *BPlusTree) Range(lo, hi int) iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for leaf := t.findLeafNode(lo); leaf != nil; leaf = leaf.Next {
+			for i, key := range leaf.Keys {
+				if key < lo {
+					continue
+				}
+				if key >= hi || !yield(key, leaf.Values[i]) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// All returns an iterator over all keys in ascending order.
+func (t *BPlusTree) All() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for leaf := t.findLeafNode(math.MinInt); leaf != nil; leaf = leaf.Next {
+			for i, key := range leaf.Keys {
+				if !yield(key, leaf.Values[i]) {
+					return
+				}
+			}
+		}
+	}
+}
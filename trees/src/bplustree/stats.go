@@ -0,0 +1,53 @@
+package bplustree
+
+// Counters counts the node splits performed by the tree, for benchmarks.
+// Counting is disabled if BPlusTree.Counters is nil.
+//
+//	tree.Counters = &Counters{}
+type Counters struct {
+	LeafSplits     uint64
+	InternalSplits uint64
+}
+
+// Stats describes the shape of the tree.
+type Stats struct {
+	Height         int       // number of levels, a tree with only a root leaf has height 1
+	Nodes          int       // internal nodes + leaf nodes
+	Leaves         int       // leaf nodes
+	Keys           int       // keys in leaf nodes
+	FillFactor     []float64 // keys / (nodes * MaxKey) of each level, from root to leaves
+	AvgKeysPerLeaf float64
+	Splits         uint64
+}
+
+// Stats returns the statistics of the tree, it walks the tree level by level.
+func (t *BPlusTree) Stats() Stats {
+	var s Stats
+
+	level := []*Node{t.Root}
+	for len(level) > 0 {
+		var next []*Node
+		keys := 0
+		for _, node := range level {
+			keys += len(node.Keys)
+			if node.IsLeaf {
+				s.Leaves++
+				s.Keys += len(node.Keys)
+			} else {
+				next = append(next, node.Children...)
+			}
+		}
+
+		s.Height++
+		s.Nodes += len(level)
+		s.FillFactor = append(s.FillFactor, float64(keys)/float64(len(level)*(t.order-1)))
+		level = next
+	}
+
+	s.AvgKeysPerLeaf = float64(s.Keys) / float64(s.Leaves)
+
+	if t.Counters != nil {
+		s.Splits = t.Counters.LeafSplits + t.Counters.InternalSplits
+	}
+	return s
+}
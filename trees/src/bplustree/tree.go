@@ -2,6 +2,8 @@ package bplustree
 
 import (
 	"fmt"
+	"io"
+	"iter"
 	"os"
 	"slices"
 )
@@ -9,17 +11,34 @@ import (
 const (
 	// B+ 树的阶，非叶子节点的子节点个数范围是 [ceil(order/2), order]
 	// (order-1) keys, (order) children.
-	order = 4 // 最多3个key, 4个children, 相当于 MaxKey=3
+	defaultOrder = 4 // 最多3个key, 4个children, 相当于 MaxKey=3
 )
 
 type BPlusTree struct {
-	Root *Node
+	Root     *Node
+	Counters *Counters // Optional operation counters, nil means disabled
+	order    int
+	size     int
 }
 
 func NewBPlusTree() *BPlusTree {
+	return NewBPlusTreeWithOrder(defaultOrder)
+}
+
+// NewBPlusTreeWithOrder creates a B+Tree of the given order, order must be at least 3.
+func NewBPlusTreeWithOrder(order int) *BPlusTree {
+	if order < 3 {
+		panic("bplustree: order must be at least 3")
+	}
+
 	// Create a new leaf node as the root
-	root := NewNode(true)
-	return &BPlusTree{Root: root}
+	root := newNode(true, order)
+	return &BPlusTree{Root: root, order: order}
+}
+
+// Order returns the order of the tree.
+func (t *BPlusTree) Order() int {
+	return t.order
 }
 
 // findLeafNode finds the leaf node that would contain the given key
@@ -49,41 +68,81 @@ func (t *BPlusTree) Search(key int) (*Node, error) {
 	leaf := t.findLeafNode(key)
 
 	// Now we are at a leaf node, search for the key
-	if slices.Contains(leaf.Keys, key) {
+	if _, found := slices.BinarySearch(leaf.Keys, key); found {
 		return leaf, nil // Key found
 	}
 
 	// Key not found
-	return nil, os.ErrNotExist
+	return nil, &KeyError{Op: "search", Key: key, Err: ErrKeyNotFound}
 }
 
+// Insert inserts key with a nil value, returns ErrDuplicateKey if the key already exists.
 func (t *BPlusTree) Insert(key int) error {
+	if !t.insert(key, nil, false) {
+		return &KeyError{Op: "insert", Key: key, Err: ErrDuplicateKey}
+	}
+	return nil
+}
+
+// insert inserts key and value, if the key already exists the value is replaced only if replace is true.
+// returns false if the key already exists.
+func (t *BPlusTree) insert(key int, value any, replace bool) bool {
 	// Find the leaf node where the key should be inserted
 	leaf := t.findLeafNode(key)
 
 	// Check if the key already exists
-	if slices.Contains(leaf.Keys, key) {
-		return os.ErrExist
+	i, found := slices.BinarySearch(leaf.Keys, key)
+	if found {
+		if replace {
+			leaf.Values[i] = value
+		}
+		return false
 	}
 
-	// insert key
-	leaf.Keys = append(leaf.Keys, key)
-	slices.Sort(leaf.Keys)
+	// insert key & value
+	leaf.Keys = slices.Insert(leaf.Keys, i, key)
+	leaf.Values = slices.Insert(leaf.Values, i, value)
+	t.size++
 
 	// Handle the case where the leaf node is full
-	if len(leaf.Keys) >= order {
+	if len(leaf.Keys) >= t.order {
 		newNode, pk := leaf.SplitNode()
-		newRoot := leaf.InsertIntoParent(newNode, pk)
+		if t.Counters != nil {
+			t.Counters.LeafSplits++
+		}
+		newRoot := insertIntoParent(leaf, newNode, pk, t.Counters)
 		if newRoot != nil {
 			t.Root = newRoot
 		}
 	}
 
-	return nil
+	return true
+}
+
+// Ascend returns an iterator over all keys >= from in ascending order,
+// it follows the Next pointer of leaf nodes.
+func (t *BPlusTree) Ascend(from int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for leaf := t.findLeafNode(from); leaf != nil; leaf = leaf.Next {
+			for _, key := range leaf.Keys {
+				if key < from {
+					continue
+				}
+				if !yield(key) {
+					return
+				}
+			}
+		}
+	}
 }
 
 // PrintTree prints the tree structure for debugging
 func PrintTree(node *Node, level int) {
+	FprintTree(os.Stdout, node, level)
+}
+
+// FprintTree prints the subtree rooted at node to w, level is the indentation of node.
+func FprintTree(w io.Writer, node *Node, level int) {
 	if node == nil {
 		return
 	}
@@ -93,24 +152,24 @@ func PrintTree(node *Node, level int) {
 		indent += "\t"
 	}
 
-	fmt.Printf("%sNode(", indent)
+	fmt.Fprintf(w, "%sNode(", indent)
 	if node.IsLeaf {
-		fmt.Printf("Leaf): Keys: %v", node.Keys)
+		fmt.Fprintf(w, "Leaf): Keys: %v", node.Keys)
 		if node.Parent != nil {
-			fmt.Printf(", parent: %v", node.Parent.Keys)
+			fmt.Fprintf(w, ", parent: %v", node.Parent.Keys)
 		}
 		if node.Next != nil {
-			fmt.Printf(", next: %v", node.Next.Keys)
+			fmt.Fprintf(w, ", next: %v", node.Next.Keys)
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	} else {
-		fmt.Printf("Internal): Keys: %v", node.Keys)
+		fmt.Fprintf(w, "Internal): Keys: %v", node.Keys)
 		if node.Parent != nil {
-			fmt.Printf(", parent: %v", node.Parent.Keys)
+			fmt.Fprintf(w, ", parent: %v", node.Parent.Keys)
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 		for _, child := range node.Children {
-			PrintTree(child, level+1)
+			FprintTree(w, child, level+1)
 		}
 	}
 }
@@ -1,7 +1,14 @@
 package bplustree
 
 import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
 	"testing"
+
+	"local/src/orderedmap"
+	"local/src/orderedmap/orderedmaptest"
 )
 
 func TestInsertFind(t *testing.T) {
@@ -28,3 +35,107 @@ func TestInsertFind(t *testing.T) {
 	s, _ = tree.Search(11)
 	t.Logf("%+v", s)
 }
+
+func TestErrors(t *testing.T) {
+	tree := NewBPlusTree()
+
+	for _, k := range rand.Perm(100) {
+		if err := tree.Insert(k); err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := tree.Insert(42)
+	if !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("Insert(42) = %v, want ErrDuplicateKey", err)
+	}
+
+	_, err = tree.Search(100)
+	var ke *KeyError
+	if !errors.Is(err, ErrKeyNotFound) || !errors.As(err, &ke) || ke.Key != 100 {
+		t.Errorf("Search(100) = %v, want ErrKeyNotFound", err)
+	}
+	t.Log(err)
+
+	// break the tree
+	leaf := tree.findLeafNode(0)
+	leaf.Keys[0] = 1000
+	if err := tree.Validate(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Validate() = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	tree := NewBPlusTree()
+	tree.Counters = &Counters{}
+
+	for i := range 1000 {
+		if err := tree.Insert(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := tree.Stats()
+	t.Logf("%+v", s)
+
+	if s.Keys != 1000 || len(s.FillFactor) != s.Height {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if uint64(s.Leaves) != tree.Counters.LeafSplits+1 {
+		t.Errorf("leaves %d, leaf splits %d", s.Leaves, tree.Counters.LeafSplits)
+	}
+	// every split creates one node, every root split creates one more root node.
+	if uint64(s.Nodes) != 1+s.Splits+uint64(s.Height-1) {
+		t.Errorf("nodes %d, splits %d, height %d", s.Nodes, s.Splits, s.Height)
+	}
+}
+
+func TestAscend(t *testing.T) {
+	tree := NewBPlusTreeWithOrder(5)
+	for _, k := range rand.Perm(100) {
+		if err := tree.Insert(k * 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := slices.Collect(tree.Ascend(51))
+	if len(got) != 74 || got[0] != 52 || !slices.IsSorted(got) {
+		t.Errorf("Ascend(51) = %v", got)
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	for _, order := range []int{3, 4, 5, 16} {
+		t.Run(fmt.Sprintf("order=%d", order), func(t *testing.T) {
+			orderedmaptest.Run(t, func() orderedmap.OrderedMap[int, any] { return NewBPlusTreeWithOrder(order) })
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	for _, order := range []int{3, 4, 5, 16} {
+		tree := NewBPlusTreeWithOrder(order)
+		keys := rand.Perm(1000)
+		for _, k := range keys {
+			tree.Put(k, k)
+		}
+
+		for i, k := range rand.Perm(1000) {
+			if !tree.Delete(k) {
+				t.Fatalf("order %d: Delete(%d) = false", order, k)
+			}
+			if err := tree.Validate(); err != nil {
+				t.Fatalf("order %d: after %d deletes: %v", order, i+1, err)
+			}
+		}
+		if tree.Len() != 0 || !tree.Root.IsLeaf {
+			t.Errorf("order %d: tree is not empty, len %d", order, tree.Len())
+		}
+	}
+}
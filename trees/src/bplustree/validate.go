@@ -0,0 +1,99 @@
+package bplustree
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Validate checks the B+Tree invariants, returns an error wrapping ErrCorrupt if any of them is violated.
+//
+//   - keys in every node are sorted and unique, a node has at most (order-1) keys,
+//     a non-root node has at least minKeys keys.
+//   - a leaf node has a value for every key.
+//   - an internal node has len(Keys)+1 children, and every child points back to it.
+//   - keys in Children[i] are in [Keys[i-1], Keys[i]).
+//   - all leaf nodes are at the same depth, and linked by Next in ascending order.
+func (t *BPlusTree) Validate() error {
+	if t.Root == nil {
+		return fmt.Errorf("%w: nil root", ErrCorrupt)
+	}
+	if t.Root.Parent != nil {
+		return fmt.Errorf("%w: root has parent %v", ErrCorrupt, t.Root.Parent.Keys)
+	}
+
+	var leaves []*Node
+	leafDepth := -1
+
+	// lo, hi are nil if the node has no lower or upper bound.
+	var validate func(node *Node, lo, hi *int, depth int) error
+	validate = func(node *Node, lo, hi *int, depth int) error {
+		if node.order != t.order {
+			return fmt.Errorf("%w: node %v has order %d, want %d", ErrCorrupt, node.Keys, node.order, t.order)
+		}
+		if len(node.Keys) >= t.order {
+			return fmt.Errorf("%w: node %v has too many keys", ErrCorrupt, node.Keys)
+		}
+		if node != t.Root && len(node.Keys) < t.minKeys() {
+			return fmt.Errorf("%w: node %v has too few keys", ErrCorrupt, node.Keys)
+		}
+		if !slices.IsSorted(node.Keys) || len(slices.Compact(slices.Clone(node.Keys))) != len(node.Keys) {
+			return fmt.Errorf("%w: node %v keys are not sorted", ErrCorrupt, node.Keys)
+		}
+		if len(node.Keys) > 0 && lo != nil && node.Keys[0] < *lo {
+			return fmt.Errorf("%w: node %v keys less than %d", ErrCorrupt, node.Keys, *lo)
+		}
+		if len(node.Keys) > 0 && hi != nil && node.Keys[len(node.Keys)-1] >= *hi {
+			return fmt.Errorf("%w: node %v keys not less than %d", ErrCorrupt, node.Keys, *hi)
+		}
+
+		if node.IsLeaf {
+			if len(node.Values) != len(node.Keys) {
+				return fmt.Errorf("%w: leaf %v has %d values", ErrCorrupt, node.Keys, len(node.Values))
+			}
+			if leafDepth < 0 {
+				leafDepth = depth
+			} else if leafDepth != depth {
+				return fmt.Errorf("%w: leaf %v at depth %d, want %d", ErrCorrupt, node.Keys, depth, leafDepth)
+			}
+			leaves = append(leaves, node)
+			return nil
+		}
+
+		if len(node.Children) != len(node.Keys)+1 {
+			return fmt.Errorf("%w: node %v has %d children", ErrCorrupt, node.Keys, len(node.Children))
+		}
+		for i, child := range node.Children {
+			if child.Parent != node {
+				return fmt.Errorf("%w: child %v has wrong parent", ErrCorrupt, child.Keys)
+			}
+			childLo, childHi := lo, hi
+			if i > 0 {
+				childLo = &node.Keys[i-1]
+			}
+			if i < len(node.Keys) {
+				childHi = &node.Keys[i]
+			}
+			if err := validate(child, childLo, childHi, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := validate(t.Root, nil, nil, 0); err != nil {
+		return err
+	}
+
+	// leaf linked list must visit every leaf from left to right.
+	for i, leaf := range leaves {
+		var next *Node
+		if i+1 < len(leaves) {
+			next = leaves[i+1]
+		}
+		if leaf.Next != next {
+			return fmt.Errorf("%w: leaf %v has wrong next pointer", ErrCorrupt, leaf.Keys)
+		}
+	}
+
+	return nil
+}
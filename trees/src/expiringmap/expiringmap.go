@@ -0,0 +1,240 @@
+// Package expiringmap implements a map whose entries expire after a TTL.
+//
+// Entries are stored in a map for lookup, and in an RBTree ordered by expiry time,
+// so expired entries are always popped from the tree minimum.
+//
+//	map:    key -> {value, expireAt}
+//	RBTree: expireAt -> []key  // 多个 key 可能同时过期
+//
+// expireAt 是从 map 创建开始经过的 nanoseconds, 使用 time.Since 计算, 所以使用的是 monotonic clock,
+// wall clock 的跳变 (NTP, suspend) 不会让 entry 提前过期或者不过期.
+package expiringmap
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"local/src/redblacktree"
+)
+
+// EvictReason tells why an entry was removed from the map.
+type EvictReason int
+
+const (
+	Expired EvictReason = iota
+	Deleted
+	Replaced
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case Expired:
+		return "expired"
+	case Deleted:
+		return "deleted"
+	case Replaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+type entry[V any] struct {
+	value    V
+	expireAt int // nanoseconds since ExpiringMap.epoch
+}
+
+// ExpiringMap is a map whose entries expire after a TTL, it is safe for concurrent use.
+//
+// Expired entries are removed on access (Get, Set, Len), or by the background sweeper
+// started by StartSweeper.
+type ExpiringMap[K comparable, V any] struct {
+	mu      sync.Mutex
+	epoch   time.Time // has a monotonic clock reading
+	entries map[K]entry[V]
+	expiry  *redblacktree.RBTree // expireAt -> []K
+	onEvict func(key K, value V, reason EvictReason)
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates an ExpiringMap, onEvict is called after an entry is removed, it can be nil.
+// onEvict is called without holding the lock, so it can access the map.
+func New[K comparable, V any](onEvict func(key K, value V, reason EvictReason)) *ExpiringMap[K, V] {
+	return &ExpiringMap[K, V]{
+		epoch:   time.Now(),
+		entries: make(map[K]entry[V]),
+		expiry:  redblacktree.NewRBTree(),
+		onEvict: onEvict,
+	}
+}
+
+type evicted[K comparable, V any] struct {
+	key    K
+	value  V
+	reason EvictReason
+}
+
+// Set adds or replaces the value of key, the entry expires after ttl.
+func (m *ExpiringMap[K, V]) Set(key K, value V, ttl time.Duration) {
+	now := m.now()
+
+	m.mu.Lock()
+	evs := m.sweep(now)
+	if old, ok := m.entries[key]; ok {
+		m.remove(key, old)
+		evs = append(evs, evicted[K, V]{key, old.value, Replaced})
+	}
+	e := entry[V]{value: value, expireAt: now + int(ttl)}
+	m.entries[key] = e
+	m.addExpiry(key, e.expireAt)
+	m.mu.Unlock()
+
+	m.notify(evs)
+}
+
+// Get returns the value of key, expired entries are not returned.
+func (m *ExpiringMap[K, V]) Get(key K) (V, bool) {
+	m.mu.Lock()
+	evs := m.sweep(m.now())
+	e, ok := m.entries[key]
+	m.mu.Unlock()
+
+	m.notify(evs)
+	return e.value, ok
+}
+
+// Delete removes key from the map, returns false if the key doesn't exist or has expired.
+func (m *ExpiringMap[K, V]) Delete(key K) bool {
+	m.mu.Lock()
+	evs := m.sweep(m.now())
+	e, ok := m.entries[key]
+	if ok {
+		m.remove(key, e)
+		evs = append(evs, evicted[K, V]{key, e.value, Deleted})
+	}
+	m.mu.Unlock()
+
+	m.notify(evs)
+	return ok
+}
+
+// Len returns the number of entries which have not expired.
+func (m *ExpiringMap[K, V]) Len() int {
+	m.mu.Lock()
+	evs := m.sweep(m.now())
+	n := len(m.entries)
+	m.mu.Unlock()
+
+	m.notify(evs)
+	return n
+}
+
+// Sweep removes all expired entries, returns the number of removed entries.
+func (m *ExpiringMap[K, V]) Sweep() int {
+	m.mu.Lock()
+	evs := m.sweep(m.now())
+	m.mu.Unlock()
+
+	m.notify(evs)
+	return len(evs)
+}
+
+// StartSweeper starts a goroutine which calls Sweep every interval, until Close is called.
+func (m *ExpiringMap[K, V]) StartSweeper(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.stop != nil {
+		return // already started
+	}
+	m.stop = make(chan struct{})
+
+	stop := m.stop
+	m.wg.Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				m.Sweep()
+			case <-stop:
+				return
+			}
+		}
+	})
+}
+
+// Close stops the background sweeper and waits for it to exit.
+func (m *ExpiringMap[K, V]) Close() {
+	m.mu.Lock()
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+// now returns the monotonic time since m.epoch, time.Since uses the monotonic clock reading of epoch.
+func (m *ExpiringMap[K, V]) now() int {
+	return int(time.Since(m.epoch))
+}
+
+// sweep pops expired entries from the tree minimum, m.mu must be held.
+func (m *ExpiringMap[K, V]) sweep(now int) []evicted[K, V] {
+	var evs []evicted[K, V]
+	for {
+		node := m.minExpiry()
+		if node == nil || node.Key > now {
+			return evs
+		}
+
+		for _, key := range node.Value.([]K) {
+			evs = append(evs, evicted[K, V]{key, m.entries[key].value, Expired})
+			delete(m.entries, key)
+		}
+		m.expiry.Delete(node.Key)
+	}
+}
+
+func (m *ExpiringMap[K, V]) minExpiry() *redblacktree.Node {
+	for node := range m.expiry.Ascend(math.MinInt) {
+		return node
+	}
+	return nil
+}
+
+func (m *ExpiringMap[K, V]) addExpiry(key K, expireAt int) {
+	var keys []K
+	if v, ok := m.expiry.Get(expireAt); ok {
+		keys = v.([]K)
+	}
+	m.expiry.Insert(expireAt, append(keys, key))
+}
+
+// remove deletes key from the map and from the expiry tree.
+func (m *ExpiringMap[K, V]) remove(key K, e entry[V]) {
+	delete(m.entries, key)
+
+	v, _ := m.expiry.Get(e.expireAt)
+	keys := slices.DeleteFunc(v.([]K), func(k K) bool { return k == key })
+	if len(keys) == 0 {
+		m.expiry.Delete(e.expireAt)
+	} else {
+		m.expiry.Insert(e.expireAt, keys)
+	}
+}
+
+func (m *ExpiringMap[K, V]) notify(evs []evicted[K, V]) {
+	if m.onEvict == nil {
+		return
+	}
+	for _, ev := range evs {
+		m.onEvict(ev.key, ev.value, ev.reason)
+	}
+}
@@ -0,0 +1,84 @@
+package expiringmap
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+// synctest 控制时间, time.Sleep 不会真的等待, 测试结果是确定的.
+func TestExpiringMap(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var evicted []string
+		m := New(func(key string, value int, reason EvictReason) {
+			evicted = append(evicted, fmt.Sprintf("%s=%d:%s", key, value, reason))
+		})
+
+		m.Set("a", 1, time.Second)
+		m.Set("b", 2, 2*time.Second)
+		m.Set("c", 3, 2*time.Second) // same expiry as "b"
+		m.Set("d", 4, 3*time.Second)
+
+		if v, ok := m.Get("a"); !ok || v != 1 {
+			t.Errorf("Get(a) = %d, %t", v, ok)
+		}
+
+		time.Sleep(time.Second)
+		if _, ok := m.Get("a"); ok {
+			t.Error("a should expire after 1s")
+		}
+		if m.Len() != 3 {
+			t.Errorf("Len() = %d, want 3", m.Len())
+		}
+
+		m.Set("b", 20, 5*time.Second) // replace, extends the expiry of "b" only
+		if !m.Delete("d") || m.Delete("d") {
+			t.Error("Delete(d) should succeed only once")
+		}
+
+		time.Sleep(time.Second)
+		if _, ok := m.Get("c"); ok {
+			t.Error("c should expire after 2s")
+		}
+		if v, ok := m.Get("b"); !ok || v != 20 {
+			t.Errorf("Get(b) = %d, %t", v, ok)
+		}
+
+		want := []string{"a=1:expired", "b=2:replaced", "d=4:deleted", "c=3:expired"}
+		if !slices.Equal(evicted, want) {
+			t.Errorf("evicted = %v, want %v", evicted, want)
+		}
+	})
+}
+
+func TestSweeper(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var mu sync.Mutex
+		var evicted []int
+		m := New(func(key, _ int, _ EvictReason) {
+			mu.Lock()
+			evicted = append(evicted, key)
+			mu.Unlock()
+		})
+		m.StartSweeper(100 * time.Millisecond)
+		defer m.Close()
+
+		for i := range 10 {
+			m.Set(i, i, time.Duration(10-i)*time.Second)
+		}
+
+		// the sweeper evicts entries in expiry order without any access.
+		time.Sleep(5*time.Second + time.Millisecond)
+		synctest.Wait()
+
+		mu.Lock()
+		got := slices.Clone(evicted)
+		mu.Unlock()
+		if want := []int{9, 8, 7, 6, 5}; !slices.Equal(got, want) {
+			t.Errorf("evicted = %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"strconv"
+	"strings"
+
+	"local/src/bplustree"
+	"local/src/redblacktree"
+)
+
+// explorerTree is the part of RBTree and BPlusTree used by the explorer.
+type explorerTree interface {
+	Get(key int) (any, bool)
+	Put(key int, value any)
+	Delete(key int) bool
+	Len() int
+	Range(lo, hi int) iter.Seq2[int, any]
+	Validate() error
+
+	print(w io.Writer)
+	stats(w io.Writer)
+	dot(w io.Writer) error
+}
+
+type rbTree struct {
+	*redblacktree.RBTree
+}
+
+func newRBTree() rbTree {
+	t := redblacktree.NewRBTree()
+	t.Counters = &redblacktree.Counters{}
+	return rbTree{t}
+}
+
+func (t rbTree) print(w io.Writer) {
+	t.FprintTree(w)
+}
+
+func (t rbTree) stats(w io.Writer) {
+	s := t.Stats()
+	fmt.Fprintf(w, "nodes=%d height=%d black-height=%d rotations=%d\n", s.Nodes, s.Height, s.BlackHeight, s.Rotations)
+}
+
+// dot writes the tree in graphviz format, NIL children are drawn as points.
+// Node IDs are quoted because keys can be negative.
+//
+//	dot -Tsvg tree.dot -o tree.svg
+func (t rbTree) dot(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintln(w, "digraph RBTree {")
+	fmt.Fprintln(w, "\tnode [shape=circle, style=filled, fontcolor=white];")
+	nils := 0
+	t.InOrderTraversal(func(x *redblacktree.Node) {
+		color := "red"
+		if x.Color == redblacktree.BLACK {
+			color = "black"
+		}
+		fmt.Fprintf(w, "\t\"n%d\" [label=\"%d\", fillcolor=%s];\n", x.Key, x.Key, color)
+		for _, child := range []*redblacktree.Node{x.Left, x.Right} {
+			if child == t.NIL {
+				nils++
+				fmt.Fprintf(w, "\tnil%d [shape=point];\n\t\"n%d\" -> nil%d;\n", nils, x.Key, nils)
+			} else {
+				fmt.Fprintf(w, "\t\"n%d\" -> \"n%d\";\n", x.Key, child.Key)
+			}
+		}
+	})
+	fmt.Fprintln(w, "}")
+	// bufio.Writer keeps the first write error, Flush returns it.
+	return w.Flush()
+}
+
+type bpTree struct {
+	*bplustree.BPlusTree
+}
+
+func newBPTree(order int) bpTree {
+	t := bplustree.NewBPlusTreeWithOrder(order)
+	t.Counters = &bplustree.Counters{}
+	return bpTree{t}
+}
+
+func (t bpTree) print(w io.Writer) {
+	bplustree.FprintTree(w, t.Root, 0)
+}
+
+func (t bpTree) stats(w io.Writer) {
+	s := t.Stats()
+	fmt.Fprintf(w, "order=%d height=%d nodes=%d leaves=%d keys=%d avg-keys-per-leaf=%.2f splits=%d\n",
+		t.Order(), s.Height, s.Nodes, s.Leaves, s.Keys, s.AvgKeysPerLeaf, s.Splits)
+}
+
+// dot writes the tree in graphviz format, leaves are linked by dashed Next edges.
+func (t bpTree) dot(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintln(w, "digraph BPlusTree {")
+	fmt.Fprintln(w, "\tnode [shape=record];")
+
+	ids := make(map[*bplustree.Node]int)
+	id := func(n *bplustree.Node) int {
+		if _, ok := ids[n]; !ok {
+			ids[n] = len(ids)
+		}
+		return ids[n]
+	}
+
+	var leaves []*bplustree.Node
+	var walk func(n *bplustree.Node)
+	walk = func(n *bplustree.Node) {
+		keys := make([]string, len(n.Keys))
+		for i, k := range n.Keys {
+			keys[i] = strconv.Itoa(k)
+		}
+		fmt.Fprintf(w, "\tn%d [label=\"%s\"];\n", id(n), strings.Join(keys, "|"))
+		if n.IsLeaf {
+			leaves = append(leaves, n)
+			return
+		}
+		for _, child := range n.Children {
+			fmt.Fprintf(w, "\tn%d -> n%d;\n", id(n), id(child))
+			walk(child)
+		}
+	}
+	walk(t.Root)
+
+	for _, leaf := range leaves {
+		if leaf.Next != nil {
+			fmt.Fprintf(w, "\tn%d -> n%d [style=dashed, constraint=false];\n", id(leaf), id(leaf.Next))
+		}
+	}
+	fmt.Fprintln(w, "}")
+	return w.Flush()
+}
+
+var errQuit = errors.New("quit")
+
+const help = `commands:
+  insert <key> [value]   insert or replace a key
+  delete <key>           delete a key
+  get <key>              print the value of a key
+  range <lo> <hi>        print keys in [lo, hi)
+  print                  print the tree structure
+  stats                  print the tree statistics
+  validate               check the tree invariants
+  dot [file]             write the tree in graphviz format
+  help                   print this help
+  quit                   exit
+`
+
+// explorer executes commands against a tree and writes the results to out.
+type explorer struct {
+	tree  explorerTree
+	out   io.Writer
+	check bool // validate the tree after every insert and delete
+}
+
+// load inserts "key [value]" lines from r, blank lines and lines starting with # are skipped.
+func (e *explorer) load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		key, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		e.tree.Put(key, value(fields[1:]))
+	}
+	return scanner.Err()
+}
+
+// run executes the commands read from r, one per line.
+// If prompt is not empty, it is written before reading each line and errors don't stop the loop,
+// otherwise r is a script and run stops at the first error, the error reports the line number.
+func (e *explorer) run(r io.Reader, prompt string) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for {
+		fmt.Fprint(e.out, prompt)
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+		line++
+
+		err := e.exec(scanner.Text())
+		if errors.Is(err, errQuit) {
+			return nil
+		}
+		if err != nil {
+			if prompt == "" {
+				return fmt.Errorf("line %d: %q: %w", line, scanner.Text(), err)
+			}
+			fmt.Fprintln(e.out, "error:", err)
+		}
+	}
+}
+
+// exec executes one command, blank lines and lines starting with # are ignored.
+func (e *explorer) exec(cmdline string) error {
+	fields := strings.Fields(cmdline)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return nil
+	}
+	cmd, args := fields[0], fields[1:]
+
+	ints := func(n int) ([]int, error) {
+		if len(args) < n {
+			return nil, fmt.Errorf("%s: need %d arguments", cmd, n)
+		}
+		keys := make([]int, n)
+		for i := range keys {
+			k, err := strconv.Atoi(args[i])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", cmd, err)
+			}
+			keys[i] = k
+		}
+		return keys, nil
+	}
+
+	switch cmd {
+	case "insert", "i":
+		keys, err := ints(1)
+		if err != nil {
+			return err
+		}
+		e.tree.Put(keys[0], value(args[1:]))
+		return e.validateIfChecked()
+
+	case "delete", "d":
+		keys, err := ints(1)
+		if err != nil {
+			return err
+		}
+		if !e.tree.Delete(keys[0]) {
+			fmt.Fprintf(e.out, "%d not found\n", keys[0])
+		}
+		return e.validateIfChecked()
+
+	case "get", "g":
+		keys, err := ints(1)
+		if err != nil {
+			return err
+		}
+		if v, ok := e.tree.Get(keys[0]); ok {
+			e.printKey(keys[0], v)
+		} else {
+			fmt.Fprintf(e.out, "%d not found\n", keys[0])
+		}
+
+	case "range", "r":
+		keys, err := ints(2)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for k, v := range e.tree.Range(keys[0], keys[1]) {
+			e.printKey(k, v)
+			n++
+		}
+		fmt.Fprintf(e.out, "(%d keys)\n", n)
+
+	case "print", "p":
+		e.tree.print(e.out)
+
+	case "stats":
+		e.tree.stats(e.out)
+
+	case "validate":
+		if err := e.tree.Validate(); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.out, "ok, %d keys\n", e.tree.Len())
+
+	case "dot":
+		if len(args) == 0 {
+			return e.tree.dot(e.out)
+		}
+		f, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		return errors.Join(e.tree.dot(f), f.Close())
+
+	case "help", "?":
+		fmt.Fprint(e.out, help)
+
+	case "quit", "exit", "q":
+		return errQuit
+
+	default:
+		return fmt.Errorf("unknown command %q, type help for commands", cmd)
+	}
+	return nil
+}
+
+// value returns the value of the remaining fields of a line, nil if there is none.
+func value(fields []string) any {
+	if len(fields) == 0 {
+		return nil
+	}
+	return strings.Join(fields, " ")
+}
+
+func (e *explorer) printKey(key int, value any) {
+	if value == nil {
+		fmt.Fprintln(e.out, key)
+		return
+	}
+	fmt.Fprintf(e.out, "%d: %v\n", key, value)
+}
+
+func (e *explorer) validateIfChecked() error {
+	if !e.check {
+		return nil
+	}
+	return e.tree.Validate()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExplorerScript(t *testing.T) {
+	script := writeFile(t, "ops.txt", `# replay
+insert 10 ten
+insert 20
+insert 5 five
+insert 15
+delete 20
+delete 99
+get 10
+get 20
+range 0 16
+validate
+stats
+dot
+`)
+
+	for _, tree := range []string{"rbtree", "bplustree"} {
+		t.Run(tree, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code := run([]string{"-tree", tree, "-order", "3", "-check", "-script", script}, strings.NewReader(""), &stdout, &stderr)
+			if code != 0 {
+				t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+			}
+
+			out := stdout.String()
+			for _, want := range []string{
+				"99 not found\n",
+				"10: ten\n",
+				"20 not found\n",
+				"5: five\n10: ten\n15\n(3 keys)\n",
+				"ok, 3 keys\n",
+				"digraph ",
+			} {
+				if !strings.Contains(out, want) {
+					t.Errorf("output does not contain %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
+
+func TestExplorerScriptError(t *testing.T) {
+	script := writeFile(t, "ops.txt", "insert 1\ninsert x\ninsert 2\n")
+
+	var stdout, stderr strings.Builder
+	if code := run([]string{"-script", script}, strings.NewReader(""), &stdout, &stderr); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "line 2") {
+		t.Errorf("stderr = %q, want line 2", stderr.String())
+	}
+}
+
+func TestExplorerLoadREPL(t *testing.T) {
+	keys := writeFile(t, "keys.txt", "3 c\n1 a\n\n# comment\n2 b\n")
+
+	var stdout, stderr strings.Builder
+	stdin := strings.NewReader("range 0 10\nbogus\nprint\nquit\nget 1\n")
+	code := run([]string{"-tree", "bplustree", "-load", keys}, stdin, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, want := range []string{"loaded 3 keys\n", "1: a\n2: b\n3: c\n", `error: unknown command "bogus"`, "Leaf): Keys: [1 2 3]"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "1: a") != 1 {
+		t.Errorf("commands after quit are executed:\n%s", out)
+	}
+}
+
+func TestExplorerLoadStdin(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-load", "-"}, strings.NewReader("1\n2\n3\n4\n"), &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if want := "loaded 4 keys\nnodes=4 "; !strings.HasPrefix(stdout.String(), want) {
+		t.Errorf("output = %q, want prefix %q", stdout.String(), want)
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, os.ErrClosed }
+
+func TestExplorerDot(t *testing.T) {
+	for _, tree := range []explorerTree{newRBTree(), newBPTree(3)} {
+		for _, k := range []int{-3, 0, 7} {
+			tree.Put(k, nil)
+		}
+
+		var out strings.Builder
+		if err := tree.dot(&out); err != nil {
+			t.Fatal(err)
+		}
+		// graphviz IDs can't start with "n-" unless they are quoted.
+		if s := out.String(); strings.Contains(s, "\tn-") || strings.Contains(s, "> n-") {
+			t.Errorf("negative key is not quoted:\n%s", out.String())
+		}
+		if err := tree.dot(failWriter{}); err == nil {
+			t.Errorf("%T.dot ignores write errors", tree)
+		}
+	}
+}
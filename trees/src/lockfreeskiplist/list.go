@@ -0,0 +1,286 @@
+// Package lockfreeskiplist implements a concurrent ordered map as a lock-free skip list.
+//
+// The algorithm is the LockFreeSkipList of "The Art of Multiprocessor Programming" (Herlihy & Shavit),
+// which extends the Harris-Michael lock-free linked list to every level of the skip list.
+//
+// 每个 next 指针和一个 marked 标记一起保存在不可变的 markedRef 中, 通过 atomic.Pointer 的 CAS 同时修改.
+// marked 表示 node 已经被逻辑删除, 之后任何遍历到它的 find() 都会把它从链表中物理删除 (snip).
+//
+//	Get:    wait-free, 不修改链表.
+//	Put:    CAS level 0 是 linearization point, 然后逐层链接上层.
+//	Delete: 从上到下 mark 每层的 next, mark level 0 是 linearization point.
+package lockfreeskiplist
+
+import (
+	"cmp"
+	"iter"
+	"math/rand/v2"
+	"sync/atomic"
+)
+
+const (
+	maxLevel = 32
+	p        = 4 // 每个 node 有 1/p 的概率升到上一层
+)
+
+type node[K cmp.Ordered, V any] struct {
+	key   K
+	value atomic.Pointer[V]
+	next  []atomic.Pointer[markedRef[K, V]]
+}
+
+// markedRef is an immutable (next, marked) pair, a new markedRef is allocated for every change.
+type markedRef[K cmp.Ordered, V any] struct {
+	node   *node[K, V]
+	marked bool
+}
+
+// List is a lock-free concurrent skip list, it is safe for concurrent use by multiple goroutines.
+// Get, Put and Delete are linearizable, iterators are weakly consistent:
+// they never yield a key twice or out of order, and reflect some of the changes made during iteration.
+type List[K cmp.Ordered, V any] struct {
+	head *node[K, V] // sentinel node, has maxLevel next pointers
+	size atomic.Int64
+}
+
+// New creates a new lock-free skip list
+func New[K cmp.Ordered, V any]() *List[K, V] {
+	head := newNode[K, V](*new(K), nil, maxLevel)
+	return &List[K, V]{head: head}
+}
+
+func newNode[K cmp.Ordered, V any](key K, value *V, level int) *node[K, V] {
+	n := &node[K, V]{key: key, next: make([]atomic.Pointer[markedRef[K, V]], level)}
+	n.value.Store(value)
+	for i := range n.next {
+		n.next[i].Store(&markedRef[K, V]{})
+	}
+	return n
+}
+
+// randomLevel returns the level of a new node, level n has probability (1/p)^(n-1).
+func randomLevel() int {
+	level := 1
+	for level < maxLevel && rand.IntN(p) == 0 { //nolint:gosec // level doesn't need crypto random.
+		level++
+	}
+	return level
+}
+
+// find fills preds and succs with the last node before key and the first node >= key at every level,
+// marked nodes found on the way are physically removed.
+// returns true if succs[0] has the key.
+func (l *List[K, V]) find(key K, preds, succs *[maxLevel]*node[K, V]) bool {
+retry:
+	pred := l.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr := pred.next[level].Load().node
+		for curr != nil {
+			ref := curr.next[level].Load()
+			for ref.marked {
+				// curr is deleted, snip it: pred -> succ
+				predRef := pred.next[level].Load()
+				if predRef.node != curr || predRef.marked {
+					goto retry // pred changed or is deleted
+				}
+				if !pred.next[level].CompareAndSwap(predRef, &markedRef[K, V]{node: ref.node}) {
+					goto retry
+				}
+				curr = ref.node
+				if curr == nil {
+					break
+				}
+				ref = curr.next[level].Load()
+			}
+			if curr == nil || curr.key >= key {
+				break
+			}
+			pred, curr = curr, ref.node
+		}
+		preds[level] = pred
+		succs[level] = curr
+	}
+	return succs[0] != nil && succs[0].key == key
+}
+
+// Get returns the value of the given key, and whether the key is found. Get never blocks or retries.
+func (l *List[K, V]) Get(key K) (V, bool) {
+	if x := l.ceiling(key); x != nil && x.key == key {
+		return *x.value.Load(), true
+	}
+	var zero V
+	return zero, false
+}
+
+// ceiling returns the first unmarked node >= key at level 0, marked nodes are skipped but not removed.
+func (l *List[K, V]) ceiling(key K) *node[K, V] {
+	pred := l.head
+	var curr *node[K, V]
+	for level := maxLevel - 1; level >= 0; level-- {
+		curr = pred.next[level].Load().node
+		for curr != nil {
+			ref := curr.next[level].Load()
+			if ref.marked {
+				curr = ref.node
+				continue
+			}
+			if curr.key >= key {
+				break
+			}
+			pred, curr = curr, ref.node
+		}
+	}
+	return curr
+}
+
+// Put adds or replaces the value of key.
+func (l *List[K, V]) Put(key K, value V) {
+	var preds, succs [maxLevel]*node[K, V]
+	topLevel := randomLevel()
+
+	for {
+		if l.find(key, &preds, &succs) {
+			x := succs[0]
+			x.value.Store(&value)
+			if !x.next[0].Load().marked {
+				return
+			}
+			// x was deleted concurrently, the value may be lost, insert a new node.
+			continue
+		}
+
+		newNode := newNode(key, &value, topLevel)
+		for i := range topLevel {
+			newNode.next[i].Store(&markedRef[K, V]{node: succs[i]})
+		}
+
+		// linearization point: link newNode at level 0
+		pred, succ := preds[0], succs[0]
+		ref := pred.next[0].Load()
+		if ref.node != succ || ref.marked || !pred.next[0].CompareAndSwap(ref, &markedRef[K, V]{node: newNode}) {
+			continue
+		}
+		l.size.Add(1)
+
+		// link the upper levels, newNode is already in the list.
+		for level := 1; level < topLevel; level++ {
+			for {
+				pred, succ := preds[level], succs[level]
+
+				nref := newNode.next[level].Load()
+				if nref.marked {
+					return // newNode is being deleted, stop linking.
+				}
+				if nref.node != succ && !newNode.next[level].CompareAndSwap(nref, &markedRef[K, V]{node: succ}) {
+					continue
+				}
+
+				ref := pred.next[level].Load()
+				if ref.node == succ && !ref.marked && pred.next[level].CompareAndSwap(ref, &markedRef[K, V]{node: newNode}) {
+					break
+				}
+				l.find(key, &preds, &succs)
+			}
+		}
+		return
+	}
+}
+
+// Delete removes key from the list, returns false if the key is not found.
+func (l *List[K, V]) Delete(key K) bool {
+	var preds, succs [maxLevel]*node[K, V]
+
+	if !l.find(key, &preds, &succs) {
+		return false
+	}
+	victim := succs[0]
+
+	// mark upper levels from top to bottom
+	for level := len(victim.next) - 1; level >= 1; level-- {
+		for {
+			ref := victim.next[level].Load()
+			if ref.marked || victim.next[level].CompareAndSwap(ref, &markedRef[K, V]{node: ref.node, marked: true}) {
+				break
+			}
+		}
+	}
+
+	// linearization point: mark level 0
+	for {
+		ref := victim.next[0].Load()
+		if ref.marked {
+			return false // deleted by another goroutine
+		}
+		if victim.next[0].CompareAndSwap(ref, &markedRef[K, V]{node: ref.node, marked: true}) {
+			l.size.Add(-1)
+			l.find(key, &preds, &succs) // physically remove victim
+			return true
+		}
+	}
+}
+
+// Len returns the number of keys, it may be stale when the list is modified concurrently.
+func (l *List[K, V]) Len() int {
+	return int(l.size.Load())
+}
+
+// Min returns the smallest key and its value, returns false if the list is empty.
+func (l *List[K, V]) Min() (K, V, bool) {
+	for k, v := range l.All() {
+		return k, v, true
+	}
+	var (
+		k K
+		v V
+	)
+	return k, v, false
+}
+
+// Max returns the largest key and its value, returns false if the list is empty.
+func (l *List[K, V]) Max() (K, V, bool) {
+	pred := l.head
+	for level := maxLevel - 1; level >= 0; level-- {
+		for curr := pred.next[level].Load().node; curr != nil; {
+			ref := curr.next[level].Load()
+			if !ref.marked {
+				pred = curr
+			}
+			curr = ref.node
+		}
+	}
+	if pred == l.head {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	return pred.key, *pred.value.Load(), true
+}
+
+// Range returns a weakly consistent iterator over keys in [lo, hi) in ascending order.
+func (l *List[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		l.ascend(l.ceiling(lo), func(k K, v V) bool {
+			return k < hi && yield(k, v)
+		})
+	}
+}
+
+// All returns a weakly consistent iterator over all keys in ascending order.
+func (l *List[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		l.ascend(l.head.next[0].Load().node, yield)
+	}
+}
+
+// ascend walks level 0 from x, marked nodes are skipped.
+func (l *List[K, V]) ascend(x *node[K, V], yield func(K, V) bool) {
+	for x != nil {
+		ref := x.next[0].Load()
+		if !ref.marked && !yield(x.key, *x.value.Load()) {
+			return
+		}
+		x = ref.node
+	}
+}
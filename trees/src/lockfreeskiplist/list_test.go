@@ -0,0 +1,152 @@
+package lockfreeskiplist
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+
+	"local/src/orderedmap"
+	"local/src/orderedmap/orderedmaptest"
+)
+
+// 并发测试需要在 race detector 下运行:
+//
+//	go test -race -count=10 ./lockfreeskiplist
+
+func TestOrderedMap(t *testing.T) {
+	orderedmaptest.Run(t, func() orderedmap.OrderedMap[int, any] { return New[int, any]() })
+}
+
+// TestStressOwnedKeys: every writer owns the keys k%writers == id, so the final state of each key is known.
+// Readers check that iterators are always sorted while writers are running.
+func TestStressOwnedKeys(t *testing.T) {
+	const (
+		writers = 8
+		readers = 4
+		keys    = 2000
+		ops     = 20000
+	)
+
+	l := New[int, int]()
+	want := make([]map[int]int, writers)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+
+	for r := range readers {
+		wg.Go(func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				prev := -1
+				for k, v := range l.Range(r*100, keys) {
+					if k <= prev {
+						t.Errorf("iterator out of order: %d after %d", k, prev)
+						return
+					}
+					if v%keys != k {
+						t.Errorf("key %d has value %d", k, v)
+						return
+					}
+					prev = k
+				}
+			}
+		})
+	}
+
+	var writerWG sync.WaitGroup
+	for id := range writers {
+		want[id] = make(map[int]int)
+		writerWG.Go(func() {
+			r := rand.New(rand.NewPCG(uint64(id), 0)) //nolint:gosec // deterministic test.
+			for i := range ops {
+				k := r.IntN(keys/writers)*writers + id
+				if r.IntN(3) == 0 {
+					_, ok := want[id][k]
+					if l.Delete(k) != ok {
+						t.Errorf("Delete(%d) = %t, want %t", k, !ok, ok)
+					}
+					delete(want[id], k)
+				} else {
+					v := i*keys + k
+					l.Put(k, v)
+					want[id][k] = v
+				}
+			}
+		})
+	}
+	writerWG.Wait()
+	close(done)
+	wg.Wait()
+
+	total := 0
+	for id := range writers {
+		total += len(want[id])
+		for k, v := range want[id] {
+			if got, ok := l.Get(k); !ok || got != v {
+				t.Errorf("Get(%d) = %d, %t, want %d", k, got, ok, v)
+			}
+		}
+	}
+
+	var got []int
+	for k := range l.All() {
+		got = append(got, k)
+	}
+	if len(got) != total || l.Len() != total || !slices.IsSorted(got) {
+		t.Errorf("got %d sorted=%t keys, Len() = %d, want %d", len(got), slices.IsSorted(got), l.Len(), total)
+	}
+}
+
+// TestStressContended: all goroutines put and delete the same small key range,
+// the size counter must match the keys left in the list.
+func TestStressContended(t *testing.T) {
+	const (
+		workers = 8
+		keys    = 16
+		ops     = 20000
+	)
+
+	l := New[int, int]()
+	var mu sync.Mutex
+	deleted := 0
+
+	var wg sync.WaitGroup
+	for id := range workers {
+		wg.Go(func() {
+			r := rand.New(rand.NewPCG(uint64(id), 1)) //nolint:gosec // deterministic test.
+			n := 0
+			for range ops {
+				k := r.IntN(keys)
+				if r.IntN(2) == 0 {
+					l.Put(k, k)
+				} else if l.Delete(k) {
+					n++
+				}
+			}
+			mu.Lock()
+			deleted += n
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	count := 0
+	prev := -1
+	for k := range l.All() {
+		if k <= prev {
+			t.Fatalf("keys out of order: %d after %d", k, prev)
+		}
+		prev = k
+		count++
+	}
+	if count != l.Len() {
+		t.Errorf("All() yields %d keys, Len() = %d", count, l.Len())
+	}
+	t.Logf("%d deletes succeeded, %d keys left", deleted, count)
+}
@@ -1,7 +1,97 @@
+// Command src is an interactive explorer of the trees in this module.
+//
+//	go run ./src [-tree rbtree|bplustree] [-order 4] [-load keys.txt|-] [-script ops.txt] [-check]
+//
+// -load inserts "key [value]" lines from a file, "-" reads them from stdin.
+// Without -script the commands are read from stdin in a REPL, type help for the commands.
+// -script replays the commands of a file and stops at the first error, with -check the tree is validated
+// after every insert and delete, so the first operation which corrupts the tree is reported:
+//
+//	# ops.txt
+//	insert 10
+//	insert 20 twenty
+//	delete 10
+//	validate
+//	dot tree.dot
 package main
 
-import "fmt"
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
 
 func main() {
-	fmt.Println("hello world") // need to run 'go mod init xxx' first
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the explorer with command line args, returns the exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("trees", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	kind := flags.String("tree", "rbtree", "tree type: rbtree or bplustree")
+	order := flags.Int("order", 4, "order of the B+Tree")
+	load := flags.String("load", "", `file of "key [value]" lines to load, - for stdin`)
+	script := flags.String("script", "", "file of commands to replay instead of the REPL")
+	check := flags.Bool("check", false, "validate the tree after every insert and delete")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	e := &explorer{out: stdout, check: *check}
+	switch *kind {
+	case "rbtree":
+		e.tree = newRBTree()
+	case "bplustree":
+		if *order < 3 {
+			fmt.Fprintln(stderr, "order must be at least 3")
+			return 2
+		}
+		e.tree = newBPTree(*order)
+	default:
+		fmt.Fprintf(stderr, "unknown tree type %q\n", *kind)
+		return 2
+	}
+
+	if *load != "" {
+		r := stdin
+		if *load != "-" {
+			f, err := os.Open(*load)
+			if err != nil {
+				fmt.Fprintln(stderr, err)
+				return 1
+			}
+			defer f.Close()
+			r = f
+		}
+		if err := e.load(r); err != nil {
+			fmt.Fprintf(stderr, "load %s: %v\n", *load, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "loaded %d keys\n", e.tree.Len())
+	}
+
+	switch {
+	case *script != "":
+		f, err := os.Open(*script)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		if err := e.run(f, ""); err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", *script, err)
+			return 1
+		}
+	case *load == "-":
+		// stdin has been used by -load
+		e.tree.stats(stdout)
+	default:
+		if err := e.run(stdin, "> "); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	return 0
 }
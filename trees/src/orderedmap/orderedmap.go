@@ -0,0 +1,34 @@
+// Package orderedmap defines the common interface of the ordered map implementations in this module,
+// so that the structure can be swapped based on the workload.
+//
+//	redblacktree.RBTree   OrderedMap[int, any]
+//	bplustree.BPlusTree   OrderedMap[int, any]
+//	avltree.Tree[K, V]    OrderedMap[K, V]
+//	skiplist.List[K, V]   OrderedMap[K, V]
+//
+// Every implementation must pass the conformance suite in package orderedmaptest.
+package orderedmap
+
+import (
+	"cmp"
+	"iter"
+)
+
+type OrderedMap[K cmp.Ordered, V any] interface {
+	// Get returns the value of key, and whether the key is found.
+	Get(key K) (V, bool)
+	// Put adds or replaces the value of key.
+	Put(key K, value V)
+	// Delete removes key, returns false if the key is not found.
+	Delete(key K) bool
+	// Len returns the number of keys.
+	Len() int
+	// Min returns the smallest key and its value, returns false if the map is empty.
+	Min() (K, V, bool)
+	// Max returns the largest key and its value, returns false if the map is empty.
+	Max() (K, V, bool)
+	// Range returns an iterator over keys in [lo, hi) in ascending order.
+	Range(lo, hi K) iter.Seq2[K, V]
+	// All returns an iterator over all keys in ascending order.
+	All() iter.Seq2[K, V]
+}
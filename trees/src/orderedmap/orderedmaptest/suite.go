@@ -0,0 +1,193 @@
+// Package orderedmaptest is the conformance test suite of orderedmap.OrderedMap.
+//
+//	func TestOrderedMap(t *testing.T) {
+//		orderedmaptest.Run(t, func() orderedmap.OrderedMap[int, any] { return NewRBTree() })
+//	}
+package orderedmaptest
+
+import (
+	"iter"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"local/src/orderedmap"
+)
+
+// Run runs all conformance tests against the maps created by newMap.
+func Run(t *testing.T, newMap func() orderedmap.OrderedMap[int, any]) {
+	t.Run("Empty", func(t *testing.T) { testEmpty(t, newMap()) })
+	t.Run("PutGet", func(t *testing.T) { testPutGet(t, newMap()) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newMap()) })
+	t.Run("Range", func(t *testing.T) { testRange(t, newMap()) })
+	t.Run("Random", func(t *testing.T) { testRandom(t, newMap()) })
+}
+
+func testEmpty(t *testing.T, m orderedmap.OrderedMap[int, any]) {
+	if m.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", m.Len())
+	}
+	if _, ok := m.Get(1); ok {
+		t.Error("Get(1) found on empty map")
+	}
+	if m.Delete(1) {
+		t.Error("Delete(1) = true on empty map")
+	}
+	if _, _, ok := m.Min(); ok {
+		t.Error("Min() found on empty map")
+	}
+	if _, _, ok := m.Max(); ok {
+		t.Error("Max() found on empty map")
+	}
+	for k := range m.All() {
+		t.Errorf("All() yields %d on empty map", k)
+	}
+}
+
+func testPutGet(t *testing.T, m orderedmap.OrderedMap[int, any]) {
+	for i := range 100 {
+		m.Put(i, i*10)
+	}
+	for i := range 100 {
+		m.Put(i, i*100) // replace
+	}
+
+	if m.Len() != 100 {
+		t.Errorf("Len() = %d, want 100", m.Len())
+	}
+	for i := range 100 {
+		if v, ok := m.Get(i); !ok || v != i*100 {
+			t.Errorf("Get(%d) = %v, %t, want %d", i, v, ok, i*100)
+		}
+	}
+	if _, ok := m.Get(100); ok {
+		t.Error("Get(100) found")
+	}
+
+	if k, v, ok := m.Min(); !ok || k != 0 || v != 0 {
+		t.Errorf("Min() = %d, %v, %t", k, v, ok)
+	}
+	if k, v, ok := m.Max(); !ok || k != 99 || v != 9900 {
+		t.Errorf("Max() = %d, %v, %t", k, v, ok)
+	}
+}
+
+func testDelete(t *testing.T, m orderedmap.OrderedMap[int, any]) {
+	for i := range 100 {
+		m.Put(i, i)
+	}
+
+	for i := 0; i < 100; i += 2 {
+		if !m.Delete(i) {
+			t.Errorf("Delete(%d) = false", i)
+		}
+		if m.Delete(i) {
+			t.Errorf("Delete(%d) twice = true", i)
+		}
+	}
+
+	if m.Len() != 50 {
+		t.Errorf("Len() = %d, want 50", m.Len())
+	}
+	if k, _, _ := m.Min(); k != 1 {
+		t.Errorf("Min() = %d, want 1", k)
+	}
+	for i := range 100 {
+		if _, ok := m.Get(i); ok != (i%2 == 1) {
+			t.Errorf("Get(%d) found = %t", i, ok)
+		}
+	}
+
+	for i := 1; i < 100; i += 2 {
+		m.Delete(i)
+	}
+	testEmpty(t, m)
+}
+
+func testRange(t *testing.T, m orderedmap.OrderedMap[int, any]) {
+	for _, k := range rand.Perm(50) {
+		m.Put(k*2, k)
+	}
+
+	var got []int
+	for k, v := range m.Range(11, 21) {
+		if v != k/2 {
+			t.Errorf("Range yields %d: %v", k, v)
+		}
+		got = append(got, k)
+	}
+	if want := []int{12, 14, 16, 18, 20}; !slices.Equal(got, want) {
+		t.Errorf("Range(11, 21) = %v, want %v", got, want)
+	}
+
+	if n := count(m.Range(20, 20)); n != 0 {
+		t.Errorf("Range(20, 20) yields %d keys", n)
+	}
+	if n := count(m.Range(-100, 1000)); n != 50 {
+		t.Errorf("Range(-100, 1000) yields %d keys, want 50", n)
+	}
+
+	// early break
+	got = got[:0]
+	for k := range m.All() {
+		got = append(got, k)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if want := []int{0, 2, 4}; !slices.Equal(got, want) {
+		t.Errorf("All() with break = %v, want %v", got, want)
+	}
+}
+
+// testRandom compares the map with the builtin map after random operations.
+func testRandom(t *testing.T, m orderedmap.OrderedMap[int, any]) {
+	r := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // deterministic test.
+	want := make(map[int]any)
+
+	for range 5000 {
+		k := r.IntN(500)
+		switch r.IntN(3) {
+		case 0, 1:
+			m.Put(k, k+1)
+			want[k] = k + 1
+		case 2:
+			_, ok := want[k]
+			if m.Delete(k) != ok {
+				t.Fatalf("Delete(%d) = %t, want %t", k, !ok, ok)
+			}
+			delete(want, k)
+		}
+	}
+
+	if m.Len() != len(want) {
+		t.Errorf("Len() = %d, want %d", m.Len(), len(want))
+	}
+
+	keys := slices.Sorted(maps.Keys(want))
+	var got []int
+	for k, v := range m.All() {
+		if v != want[k] {
+			t.Errorf("key %d: value %v, want %v", k, v, want[k])
+		}
+		got = append(got, k)
+	}
+	if !slices.Equal(got, keys) {
+		t.Errorf("All() keys = %v, want %v", got, keys)
+	}
+	if k, _, _ := m.Min(); len(keys) > 0 && k != keys[0] {
+		t.Errorf("Min() = %d, want %d", k, keys[0])
+	}
+	if k, _, _ := m.Max(); len(keys) > 0 && k != keys[len(keys)-1] {
+		t.Errorf("Max() = %d, want %d", k, keys[len(keys)-1])
+	}
+}
+
+func count[K, V any](seq iter.Seq2[K, V]) int {
+	n := 0
+	for range seq {
+		n++
+	}
+	return n
+}
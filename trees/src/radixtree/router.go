@@ -0,0 +1,212 @@
+package radixtree
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Router is an HTTP request router backed by a radix tree, it accepts a subset of the http.ServeMux patterns:
+//
+//	[METHOD ]/path
+//
+//	/index.html                    exact match
+//	GET /static/                   trailing slash matches the whole subtree
+//	/b/{bucket}/o/{objectname...}  {name} matches one segment, {name...} matches the rest of the path
+//	/foo/{$}                       matches "/foo/" only
+//
+// Patterns are stored by their literal prefix (up to the first wildcard), a request is matched against the
+// patterns of every stored prefix of its path, from the longest prefix to the shortest.
+// So precedence is "longest literal prefix wins" instead of ServeMux's "most specific wins",
+// and conflicting patterns are not detected. Host patterns and redirects are not supported.
+type Router struct {
+	routes *Tree[[]*route]
+}
+
+type route struct {
+	method   string // "" matches any method
+	pattern  string
+	segments []segment
+	handler  http.Handler
+}
+
+// segment of a path pattern.
+//
+//	"foo"       literal
+//	"{name}"    wild
+//	"{name...}" wild + multi
+//	"" at end   multi (trailing slash)
+type segment struct {
+	literal string
+	wild    string
+	multi   bool
+}
+
+// NewRouter creates an empty router
+func NewRouter() *Router {
+	return &Router{routes: New[[]*route]()}
+}
+
+// Handle registers the handler for the given pattern, it panics if the pattern is invalid or already registered.
+func (r *Router) Handle(pattern string, handler http.Handler) {
+	rt, err := parsePattern(pattern)
+	if err != nil {
+		panic(err)
+	}
+	rt.handler = handler
+
+	key := rt.pattern
+	if i := strings.IndexByte(key, '{'); i >= 0 {
+		key = key[:i]
+	}
+
+	routes, _ := r.routes.Get(key)
+	for _, other := range routes {
+		if other.method == rt.method && other.pattern == rt.pattern {
+			panic(fmt.Sprintf("radixtree: pattern %q is already registered", pattern))
+		}
+	}
+	// method specific routes are tried first
+	i := len(routes)
+	if rt.method != "" {
+		i = slices.IndexFunc(routes, func(other *route) bool { return other.method == "" })
+		if i < 0 {
+			i = len(routes)
+		}
+	}
+	routes = slices.Insert(routes, i, rt)
+	r.routes.Insert(key, routes)
+}
+
+// HandleFunc registers the handler function for the given pattern.
+func (r *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.Handle(pattern, http.HandlerFunc(handler))
+}
+
+func parsePattern(pattern string) (*route, error) {
+	method, path, found := strings.Cut(pattern, " ")
+	if !found {
+		method, path = "", pattern
+	}
+	path = strings.TrimLeft(path, " \t")
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("radixtree: pattern %q: path must start with /", pattern)
+	}
+
+	rt := &route{method: method, pattern: path}
+	parts := strings.Split(path[1:], "/")
+	for i, part := range parts {
+		last := i == len(parts)-1
+		switch {
+		case part == "" && last:
+			rt.segments = append(rt.segments, segment{multi: true})
+		case part == "{$}" && last:
+			rt.segments = append(rt.segments, segment{literal: ""})
+		case strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}"):
+			name, multi := strings.CutSuffix(part[1:len(part)-1], "...")
+			if name == "" || strings.ContainsAny(name, "{}") {
+				return nil, fmt.Errorf("radixtree: pattern %q: bad wildcard %q", pattern, part)
+			}
+			if multi && !last {
+				return nil, fmt.Errorf("radixtree: pattern %q: %q must be the last segment", pattern, part)
+			}
+			rt.segments = append(rt.segments, segment{wild: name, multi: multi})
+		case strings.ContainsAny(part, "{}"):
+			return nil, fmt.Errorf("radixtree: pattern %q: wildcard must be a whole segment", pattern)
+		default:
+			rt.segments = append(rt.segments, segment{literal: part})
+		}
+	}
+	return rt, nil
+}
+
+// match matches the path segments, returns the wildcard values and whether the path matches.
+func (rt *route) match(parts []string) (map[string]string, bool) {
+	var values map[string]string
+	for i, seg := range rt.segments {
+		if i >= len(parts) {
+			return nil, false
+		}
+		switch {
+		case seg.multi:
+			if seg.wild != "" {
+				values = setValue(values, seg.wild, strings.Join(parts[i:], "/"))
+			}
+			return values, true
+		case seg.wild != "":
+			if parts[i] == "" {
+				return nil, false
+			}
+			values = setValue(values, seg.wild, parts[i])
+		case parts[i] != seg.literal:
+			return nil, false
+		}
+	}
+	return values, len(parts) == len(rt.segments)
+}
+
+func setValue(values map[string]string, name, value string) map[string]string {
+	if values == nil {
+		values = make(map[string]string)
+	}
+	values[name] = value
+	return values
+}
+
+// methodMatches reports whether the route accepts the request method, GET routes also accept HEAD.
+func (rt *route) methodMatches(method string) bool {
+	return rt.method == "" || rt.method == method || (rt.method == http.MethodGet && method == http.MethodHead)
+}
+
+// Handler returns the handler and the pattern for the request, the path values are set on the request.
+// If no route matches, it returns a 404 or 405 handler and an empty pattern.
+func (r *Router) Handler(req *http.Request) (http.Handler, string) {
+	path := req.URL.Path
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+
+	var prefixes [][]*route
+	for _, routes := range r.routes.WalkPath(path) {
+		prefixes = append(prefixes, routes)
+	}
+
+	var allowed []string
+	for _, routes := range slices.Backward(prefixes) {
+		for _, rt := range routes {
+			values, ok := rt.match(parts)
+			if !ok {
+				continue
+			}
+			if !rt.methodMatches(req.Method) {
+				allowed = append(allowed, rt.method)
+				if rt.method == http.MethodGet {
+					allowed = append(allowed, http.MethodHead)
+				}
+				continue
+			}
+			for name, value := range values {
+				req.SetPathValue(name, value)
+			}
+			if rt.method == "" {
+				return rt.handler, rt.pattern
+			}
+			return rt.handler, rt.method + " " + rt.pattern
+		}
+	}
+
+	if len(allowed) > 0 {
+		slices.Sort(allowed)
+		allow := strings.Join(slices.Compact(allowed), ", ")
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Allow", allow)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}), ""
+	}
+	return http.NotFoundHandler(), ""
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern matches the request.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h, _ := r.Handler(req)
+	h.ServeHTTP(w, req)
+}
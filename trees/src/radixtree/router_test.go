@@ -0,0 +1,99 @@
+package radixtree
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// the patterns of go122/src/http/routing_test.go
+var patterns = []string{
+	"/index.html",
+	"GET /static/",
+	"GET /foo/",
+	"POST /foo/",
+	"/b/{bucket}/o/{objectname...}",
+	"/users/{id}",
+	"/users/{id}/posts/{$}",
+}
+
+type handler interface {
+	http.Handler
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+func register(mux handler) {
+	for _, pattern := range patterns {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "match %q", pattern)
+			for _, name := range []string{"bucket", "objectname", "id"} {
+				if v := req.PathValue(name); v != "" {
+					fmt.Fprintf(w, " %s=%s", name, v)
+				}
+			}
+		})
+	}
+}
+
+// TestRouterServeMux sends the same requests to Router and http.ServeMux, the responses must be the same.
+func TestRouterServeMux(t *testing.T) {
+	router := NewRouter()
+	register(router)
+	mux := http.NewServeMux()
+	register(mux)
+
+	requests := []struct {
+		method, path string
+	}{
+		{"GET", "/index.html"},
+		{"POST", "/index.html"},
+		{"GET", "/index.htm"},
+		{"GET", "/static/"},
+		{"HEAD", "/static/css/main.css"},
+		{"POST", "/static/"},
+		{"GET", "/foo/bar"},
+		{"POST", "/foo/bar"},
+		{"DELETE", "/foo/bar"},
+		{"GET", "/b/foo/o/bar/abc/def"},
+		{"GET", "/b/foo/o/"},
+		{"GET", "/users/42"},
+		{"GET", "/users/42/"},
+		{"GET", "/users/42/posts/"},
+		{"GET", "/users/42/posts/1"},
+		{"GET", "/nothing"},
+		// not compared: ServeMux redirects "/b/foo/o" to "/b/foo/o/" and cleans "/b//o/x", Router returns 404.
+	}
+
+	for _, r := range requests {
+		t.Run(r.method+" "+r.path, func(t *testing.T) {
+			want := httptest.NewRecorder()
+			mux.ServeHTTP(want, httptest.NewRequest(r.method, r.path, http.NoBody))
+			got := httptest.NewRecorder()
+			router.ServeHTTP(got, httptest.NewRequest(r.method, r.path, http.NoBody))
+
+			if got.Code != want.Code || got.Body.String() != want.Body.String() {
+				t.Errorf("Router: %d %q\nServeMux: %d %q", got.Code, got.Body, want.Code, want.Body)
+			}
+			if got.Header().Get("Allow") != want.Header().Get("Allow") {
+				t.Errorf("Allow = %q, want %q", got.Header().Get("Allow"), want.Header().Get("Allow"))
+			}
+		})
+	}
+}
+
+func TestRouterBadPattern(t *testing.T) {
+	for _, pattern := range []string{"index.html", "/{}", "/a/{rest...}/b", "/a{b}", "GET /x", "/static/"} {
+		t.Run(pattern, func(t *testing.T) {
+			router := NewRouter()
+			router.HandleFunc("GET /x", func(http.ResponseWriter, *http.Request) {})
+			router.HandleFunc("/static/", func(http.ResponseWriter, *http.Request) {})
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Handle(%q) did not panic", pattern)
+				}
+			}()
+			router.HandleFunc(pattern, func(http.ResponseWriter, *http.Request) {})
+		})
+	}
+}
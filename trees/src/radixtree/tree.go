@@ -0,0 +1,285 @@
+// Package radixtree implements a compressed radix tree (Patricia trie) with string keys.
+//
+// 只有一个 child 的 node 会和 child 合并, 所以每条 edge 保存的是一段 prefix 而不是单个 byte:
+//
+//	insert "/foo", "/foo/bar", "/fox"
+//
+//	      root
+//	       | "/fo"
+//	      node
+//	  "o" /   \ "x"
+//	  /foo     /fox
+//	   | "/bar"
+//	/foo/bar
+package radixtree
+
+import (
+	"iter"
+	"slices"
+	"strings"
+)
+
+type node[V any] struct {
+	prefix string // edge label from the parent node
+	leaf   bool   // node holds a key
+	value  V
+	edges  []*node[V] // sorted by prefix[0], prefixes of edges never share the first byte
+}
+
+// Tree is a compressed radix tree, keys are ordered byte-wise.
+type Tree[V any] struct {
+	root *node[V]
+	size int
+}
+
+// New creates a new radix tree
+func New[V any]() *Tree[V] {
+	return &Tree[V]{root: &node[V]{}}
+}
+
+// edge returns the index of the edge starting with b, and whether it is found.
+func (n *node[V]) edge(b byte) (int, bool) {
+	return slices.BinarySearchFunc(n.edges, b, func(e *node[V], b byte) int {
+		return int(e.prefix[0]) - int(b)
+	})
+}
+
+func (n *node[V]) addEdge(child *node[V]) {
+	i, _ := n.edge(child.prefix[0])
+	n.edges = slices.Insert(n.edges, i, child)
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree[V]) Len() int {
+	return t.size
+}
+
+// Insert adds or replaces the value of key, returns true if an existing value was replaced.
+func (t *Tree[V]) Insert(key string, value V) (updated bool) {
+	n := t.root
+	search := key
+
+	for {
+		if search == "" {
+			updated = n.leaf
+			if !updated {
+				t.size++
+			}
+			n.leaf, n.value = true, value
+			return updated
+		}
+
+		i, found := n.edge(search[0])
+		if !found {
+			n.addEdge(&node[V]{prefix: search, leaf: true, value: value})
+			t.size++
+			return false
+		}
+
+		child := n.edges[i]
+		common := commonPrefixLen(search, child.prefix)
+		if common == len(child.prefix) {
+			n = child
+			search = search[common:]
+			continue
+		}
+
+		// split the edge:  n -"abcd"-> child  =>  n -"ab"-> split -"cd"-> child
+		split := &node[V]{prefix: search[:common]}
+		n.edges[i] = split
+		child.prefix = child.prefix[common:]
+		split.addEdge(child)
+
+		search = search[common:]
+		if search == "" {
+			split.leaf, split.value = true, value
+		} else {
+			split.addEdge(&node[V]{prefix: search, leaf: true, value: value})
+		}
+		t.size++
+		return false
+	}
+}
+
+// Get returns the value of key, and whether the key is found.
+func (t *Tree[V]) Get(key string) (V, bool) {
+	n := t.root
+	search := key
+	for {
+		if search == "" {
+			if n.leaf {
+				return n.value, true
+			}
+			break
+		}
+
+		i, found := n.edge(search[0])
+		if !found || !strings.HasPrefix(search, n.edges[i].prefix) {
+			break
+		}
+		n = n.edges[i]
+		search = search[len(n.prefix):]
+	}
+
+	var zero V
+	return zero, false
+}
+
+// Delete removes key from the tree, returns false if the key is not found.
+func (t *Tree[V]) Delete(key string) bool {
+	var parent *node[V]
+	n := t.root
+	search := key
+
+	for search != "" {
+		i, found := n.edge(search[0])
+		if !found || !strings.HasPrefix(search, n.edges[i].prefix) {
+			return false
+		}
+		parent, n = n, n.edges[i]
+		search = search[len(n.prefix):]
+	}
+	if !n.leaf {
+		return false
+	}
+
+	var zero V
+	n.leaf, n.value = false, zero
+	t.size--
+
+	if n == t.root {
+		return true
+	}
+
+	// remove the empty node, then merge the parent if it has only one edge left.
+	if len(n.edges) == 0 {
+		i, _ := parent.edge(n.prefix[0])
+		parent.edges = slices.Delete(parent.edges, i, i+1)
+		if parent != t.root && !parent.leaf && len(parent.edges) == 1 {
+			mergeChild(parent)
+		}
+		return true
+	}
+
+	if len(n.edges) == 1 {
+		mergeChild(n)
+	}
+	return true
+}
+
+// mergeChild merges the only edge of n into n:  n -"cd"-> child  =>  n("ab"+"cd")
+func mergeChild[V any](n *node[V]) {
+	child := n.edges[0]
+	n.prefix += child.prefix
+	n.leaf, n.value = child.leaf, child.value
+	n.edges = child.edges
+}
+
+// LongestPrefix returns the longest key in the tree which is a prefix of key.
+func (t *Tree[V]) LongestPrefix(key string) (string, V, bool) {
+	var (
+		match string
+		value V
+		ok    bool
+	)
+
+	n := t.root
+	search := key
+	for {
+		if n.leaf {
+			match, value, ok = key[:len(key)-len(search)], n.value, true
+		}
+		if search == "" {
+			break
+		}
+
+		i, found := n.edge(search[0])
+		if !found || !strings.HasPrefix(search, n.edges[i].prefix) {
+			break
+		}
+		n = n.edges[i]
+		search = search[len(n.prefix):]
+	}
+	return match, value, ok
+}
+
+// WalkPrefix returns an iterator over all keys with the given prefix in ascending order.
+func (t *Tree[V]) WalkPrefix(prefix string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		n := t.root
+		key := "" // full key of n
+		search := prefix
+		for search != "" {
+			i, found := n.edge(search[0])
+			if !found {
+				return
+			}
+			n = n.edges[i]
+			switch {
+			case strings.HasPrefix(search, n.prefix):
+				search = search[len(n.prefix):]
+			case strings.HasPrefix(n.prefix, search):
+				// prefix ends in the middle of the edge, the whole subtree matches.
+				search = ""
+			default:
+				return
+			}
+			key += n.prefix
+		}
+		walk(n, key, yield)
+	}
+}
+
+// WalkPath returns an iterator over all keys which are prefixes of key, from the shortest to the longest.
+func (t *Tree[V]) WalkPath(key string) iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		n := t.root
+		search := key
+		for {
+			if n.leaf && !yield(key[:len(key)-len(search)], n.value) {
+				return
+			}
+			if search == "" {
+				return
+			}
+
+			i, found := n.edge(search[0])
+			if !found || !strings.HasPrefix(search, n.edges[i].prefix) {
+				return
+			}
+			n = n.edges[i]
+			search = search[len(n.prefix):]
+		}
+	}
+}
+
+// All returns an iterator over all keys in ascending order.
+func (t *Tree[V]) All() iter.Seq2[string, V] {
+	return func(yield func(string, V) bool) {
+		walk(t.root, "", yield)
+	}
+}
+
+// walk visits the subtree rooted at n in order, key is the full key of n.
+// returns false if yield returns false.
+func walk[V any](n *node[V], key string, yield func(string, V) bool) bool {
+	if n.leaf && !yield(key, n.value) {
+		return false
+	}
+	for _, child := range n.edges {
+		if !walk(child, key+child.prefix, yield) {
+			return false
+		}
+	}
+	return true
+}
+
+func commonPrefixLen(a, b string) int {
+	n := min(len(a), len(b))
+	for i := range n {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
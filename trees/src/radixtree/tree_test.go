@@ -0,0 +1,177 @@
+package radixtree
+
+import (
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func collect[V any](seq func(yield func(string, V) bool)) []string {
+	var keys []string
+	for k := range seq {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestInsertGetDelete(t *testing.T) {
+	tree := New[int]()
+	keys := []string{"/foo", "/foo/bar", "/fox", "", "/", "/foo/baz", "/f"}
+	for i, k := range keys {
+		if tree.Insert(k, i) {
+			t.Errorf("Insert(%q) = true, want false", k)
+		}
+	}
+	if !tree.Insert("/foo", 100) {
+		t.Errorf("Insert(/foo) again = false, want true")
+	}
+	if tree.Len() != len(keys) {
+		t.Errorf("Len() = %d, want %d", tree.Len(), len(keys))
+	}
+
+	for _, k := range []string{"/fo", "/foo/", "/foo/ba", "/foo/bar/", "x"} {
+		if _, ok := tree.Get(k); ok {
+			t.Errorf("Get(%q) found", k)
+		}
+	}
+	if v, ok := tree.Get("/foo"); !ok || v != 100 {
+		t.Errorf("Get(/foo) = %d, %t, want 100", v, ok)
+	}
+
+	for _, k := range []string{"/fo", "/foo/ba", "missing"} {
+		if tree.Delete(k) {
+			t.Errorf("Delete(%q) = true, want false", k)
+		}
+	}
+	for _, k := range []string{"/foo", "", "/foo/bar"} {
+		if !tree.Delete(k) {
+			t.Errorf("Delete(%q) = false, want true", k)
+		}
+	}
+
+	want := []string{"/", "/f", "/foo/baz", "/fox"}
+	if got := collect(tree.All()); !slices.Equal(got, want) {
+		t.Errorf("All() = %q, want %q", got, want)
+	}
+}
+
+func TestLongestPrefix(t *testing.T) {
+	tree := New[int]()
+	for i, k := range []string{"/", "/static/", "/static/css/", "/api"} {
+		tree.Insert(k, i)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+		ok   bool
+	}{
+		{"/static/css/main.css", "/static/css/", true},
+		{"/static/js/main.js", "/static/", true},
+		{"/static", "/", true},
+		{"/api/v1", "/api", true},
+		{"/ap", "/", true},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, _, ok := tree.LongestPrefix(tt.key)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("LongestPrefix(%q) = %q, %t, want %q, %t", tt.key, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	var path []string
+	for k := range tree.WalkPath("/static/css/x") {
+		path = append(path, k)
+	}
+	if want := []string{"/", "/static/", "/static/css/"}; !slices.Equal(path, want) {
+		t.Errorf("WalkPath() = %q, want %q", path, want)
+	}
+}
+
+func TestWalkPrefix(t *testing.T) {
+	tree := New[int]()
+	keys := []string{"romane", "romanus", "romulus", "rubens", "ruber", "rubicon", "rubicundus", "rom"}
+	for i, k := range keys {
+		tree.Insert(k, i)
+	}
+
+	for _, prefix := range []string{"", "r", "rom", "roma", "romanu", "rub", "rubic", "rx", "romulusx"} {
+		var want []string
+		for _, k := range keys {
+			if strings.HasPrefix(k, prefix) {
+				want = append(want, k)
+			}
+		}
+		slices.Sort(want)
+
+		if got := collect(tree.WalkPrefix(prefix)); !slices.Equal(got, want) {
+			t.Errorf("WalkPrefix(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+
+	// stop early
+	for k := range tree.WalkPrefix("rub") {
+		if k != "rubens" {
+			t.Errorf("first key = %q, want rubens", k)
+		}
+		break
+	}
+}
+
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // deterministic test.
+	tree := New[int]()
+	want := make(map[string]int)
+
+	randomKey := func() string {
+		b := make([]byte, r.IntN(6))
+		for i := range b {
+			b[i] = "abc/"[r.IntN(4)]
+		}
+		return string(b)
+	}
+
+	for i := range 20000 {
+		k := randomKey()
+		if r.IntN(3) == 0 {
+			_, ok := want[k]
+			if got := tree.Delete(k); got != ok {
+				t.Fatalf("Delete(%q) = %t, want %t", k, got, ok)
+			}
+			delete(want, k)
+		} else {
+			_, ok := want[k]
+			if got := tree.Insert(k, i); got != ok {
+				t.Fatalf("Insert(%q) = %t, want %t", k, got, ok)
+			}
+			want[k] = i
+		}
+	}
+
+	if tree.Len() != len(want) {
+		t.Errorf("Len() = %d, want %d", tree.Len(), len(want))
+	}
+	for k, v := range want {
+		if got, ok := tree.Get(k); !ok || got != v {
+			t.Errorf("Get(%q) = %d, %t, want %d", k, got, ok, v)
+		}
+	}
+	if got := collect(tree.All()); !slices.Equal(got, slices.Sorted(maps.Keys(want))) {
+		t.Errorf("All() is not the sorted keys")
+	}
+	checkCompressed(t, tree.root, true)
+}
+
+// checkCompressed checks that every node except the root holds a key or has at least 2 edges.
+func checkCompressed[V any](t *testing.T, n *node[V], root bool) {
+	t.Helper()
+	if !root && !n.leaf && len(n.edges) < 2 {
+		t.Errorf("node %q has %d edges and no key", n.prefix, len(n.edges))
+	}
+	for _, child := range n.edges {
+		checkCompressed(t, child, false)
+	}
+}
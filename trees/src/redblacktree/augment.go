@@ -0,0 +1,132 @@
+package redblacktree
+
+import "math"
+
+// Monoid describes the summary stored in every node of an augmented tree.
+//
+//	Node.Summary = Combine(Combine(Left.Summary, Lift(Key, Value)), Right.Summary)
+//
+// Combine must be associative and Identity must be its identity element, Combine is always called in key order,
+// so it does not have to be commutative.
+//
+// Summary 只依赖于 subtree 中的 key 和 value, 所以 rotation 只需要重新计算被旋转的两个 node,
+// recolor 不需要重新计算; insert / delete 只需要重新计算从修改的位置到 root 的 path, 都是 O(log n).
+type Monoid struct {
+	Identity any
+	Lift     func(key int, value any) any // summary of a single node
+	Combine  func(a, b any) any
+}
+
+// NewAugmentedRBTree creates a new red-black tree which maintains the subtree summary of monoid m.
+func NewAugmentedRBTree(m *Monoid) *RBTree {
+	t := NewRBTree()
+	t.Monoid = m
+	t.NIL.Summary = m.Identity
+	return t
+}
+
+// update recomputes the summary of x from its children.
+func (t *RBTree) update(x *Node) {
+	if t.Monoid == nil || x == t.NIL {
+		return
+	}
+	m := t.Monoid
+	x.Summary = m.Combine(m.Combine(x.Left.Summary, m.Lift(x.Key, x.Value)), x.Right.Summary)
+}
+
+// updatePath recomputes the summaries from x up to the root.
+func (t *RBTree) updatePath(x *Node) {
+	if t.Monoid == nil {
+		return
+	}
+	for ; x != t.NIL; x = x.Parent {
+		t.update(x)
+	}
+}
+
+// RangeAggregate returns the combined summary of keys in [lo, hi) in O(log n),
+// it returns nil if the tree is not augmented.
+//
+// 从 root 向下找到第一个 lo <= key < hi 的 node (split node), 结果是:
+//
+//	suffix(split.Left, lo) + split + prefix(split.Right, hi)
+func (t *RBTree) RangeAggregate(lo, hi int) any {
+	m := t.Monoid
+	if m == nil {
+		return nil
+	}
+
+	x := t.Root
+	for x != t.NIL {
+		if x.Key < lo {
+			x = x.Right
+		} else if x.Key >= hi {
+			x = x.Left
+		} else {
+			break
+		}
+	}
+	if x == t.NIL {
+		return m.Identity
+	}
+
+	// keys >= lo in the left subtree, the nodes found later are smaller.
+	left := m.Identity
+	for y := x.Left; y != t.NIL; {
+		if y.Key >= lo {
+			left = m.Combine(m.Combine(m.Lift(y.Key, y.Value), y.Right.Summary), left)
+			y = y.Left
+		} else {
+			y = y.Right
+		}
+	}
+
+	// keys < hi in the right subtree, the nodes found later are larger.
+	right := m.Identity
+	for y := x.Right; y != t.NIL; {
+		if y.Key < hi {
+			right = m.Combine(right, m.Combine(y.Left.Summary, m.Lift(y.Key, y.Value)))
+			y = y.Right
+		} else {
+			y = y.Left
+		}
+	}
+
+	return m.Combine(m.Combine(left, m.Lift(x.Key, x.Value)), right)
+}
+
+// CountMonoid counts the keys, the summary is an int.
+func CountMonoid() *Monoid {
+	return &Monoid{
+		Identity: 0,
+		Lift:     func(int, any) any { return 1 },
+		Combine:  func(a, b any) any { return a.(int) + b.(int) },
+	}
+}
+
+// SumMonoid sums value(v) of every node, the summary is a float64.
+func SumMonoid(value func(v any) float64) *Monoid {
+	return &Monoid{
+		Identity: 0.0,
+		Lift:     func(_ int, v any) any { return value(v) },
+		Combine:  func(a, b any) any { return a.(float64) + b.(float64) },
+	}
+}
+
+// MinMonoid is the minimum of value(v), the summary is a float64, +Inf for empty ranges.
+func MinMonoid(value func(v any) float64) *Monoid {
+	return &Monoid{
+		Identity: math.Inf(1),
+		Lift:     func(_ int, v any) any { return value(v) },
+		Combine:  func(a, b any) any { return math.Min(a.(float64), b.(float64)) },
+	}
+}
+
+// MaxMonoid is the maximum of value(v), the summary is a float64, -Inf for empty ranges.
+func MaxMonoid(value func(v any) float64) *Monoid {
+	return &Monoid{
+		Identity: math.Inf(-1),
+		Lift:     func(_ int, v any) any { return value(v) },
+		Combine:  func(a, b any) any { return math.Max(a.(float64), b.(float64)) },
+	}
+}
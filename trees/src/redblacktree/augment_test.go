@@ -0,0 +1,120 @@
+package redblacktree
+
+import (
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"testing"
+
+	"local/src/orderedmap"
+	"local/src/orderedmap/orderedmaptest"
+)
+
+func float(v any) float64 { return v.(float64) }
+
+// concatMonoid is not commutative, it checks that Combine is called in key order.
+func concatMonoid() *Monoid {
+	return &Monoid{
+		Identity: "",
+		Lift:     func(key int, _ any) any { return strconv.Itoa(key) + "," },
+		Combine:  func(a, b any) any { return a.(string) + b.(string) },
+	}
+}
+
+func TestRangeAggregate(t *testing.T) {
+	monoids := map[string]struct {
+		m    *Monoid
+		want func(values map[int]float64, lo, hi int) any
+	}{
+		"count": {CountMonoid(), func(values map[int]float64, lo, hi int) any {
+			n := 0
+			for k := range values {
+				if lo <= k && k < hi {
+					n++
+				}
+			}
+			return n
+		}},
+		"sum": {SumMonoid(float), func(values map[int]float64, lo, hi int) any {
+			sum := 0.0
+			for k, v := range values {
+				if lo <= k && k < hi {
+					sum += v
+				}
+			}
+			return sum
+		}},
+		"min": {MinMonoid(float), func(values map[int]float64, lo, hi int) any {
+			m := math.Inf(1)
+			for k, v := range values {
+				if lo <= k && k < hi {
+					m = min(m, v)
+				}
+			}
+			return m
+		}},
+		"max": {MaxMonoid(float), func(values map[int]float64, lo, hi int) any {
+			m := math.Inf(-1)
+			for k, v := range values {
+				if lo <= k && k < hi {
+					m = max(m, v)
+				}
+			}
+			return m
+		}},
+		"concat": {concatMonoid(), func(values map[int]float64, lo, hi int) any {
+			s := ""
+			for k := lo; k < hi; k++ {
+				if _, ok := values[k]; ok {
+					s += strconv.Itoa(k) + ","
+				}
+			}
+			return s
+		}},
+	}
+
+	for name, tt := range monoids {
+		t.Run(name, func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // deterministic test.
+			tree := NewAugmentedRBTree(tt.m)
+			values := make(map[int]float64)
+
+			for i := range 3000 {
+				k := r.IntN(500)
+				if r.IntN(3) == 0 {
+					tree.Delete(k)
+					delete(values, k)
+				} else {
+					v := float64(r.IntN(1000)) // small integers, so float sums are exact
+					tree.Insert(k, v)
+					values[k] = v
+				}
+
+				if i%100 != 0 {
+					continue
+				}
+				if err := tree.Validate(); err != nil {
+					t.Fatalf("after %d ops: %v", i, err)
+				}
+				for range 20 {
+					lo, hi := r.IntN(520)-10, r.IntN(520)-10
+					if got, want := tree.RangeAggregate(lo, hi), tt.want(values, lo, hi); got != want {
+						t.Fatalf("RangeAggregate(%d, %d) = %v, want %v", lo, hi, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRangeAggregateNotAugmented(t *testing.T) {
+	tree := NewRBTree()
+	tree.Insert(1, 1.0)
+	if got := tree.RangeAggregate(0, 10); got != nil {
+		t.Errorf("RangeAggregate() = %v, want nil", got)
+	}
+}
+
+func TestAugmentedOrderedMap(t *testing.T) {
+	orderedmaptest.Run(t, func() orderedmap.OrderedMap[int, any] { return NewAugmentedRBTree(CountMonoid()) })
+}
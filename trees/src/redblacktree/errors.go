@@ -0,0 +1,8 @@
+package redblacktree
+
+import "errors"
+
+var (
+	ErrCorrupt = errors.New("tree is corrupt")  // returned by Validate
+	ErrNot234  = errors.New("not a 2-3-4 tree") // returned by From234
+)
@@ -0,0 +1,59 @@
+package redblacktree
+
+import (
+	"iter"
+	"math"
+)
+
+// RBTree implements orderedmap.OrderedMap[int, any].
+
+// Put adds or replaces the value of key, it is the same as Insert.
+func (t *RBTree) Put(key int, value any) {
+	t.Insert(key, value)
+}
+
+// Len returns the number of nodes in the tree.
+func (t *RBTree) Len() int {
+	return t.size
+}
+
+// Min returns the smallest key and its value, returns false if the tree is empty.
+func (t *RBTree) Min() (int, any, bool) {
+	if t.Root == t.NIL {
+		return 0, nil, false
+	}
+	x := t.minimumNode(t.Root)
+	return x.Key, x.Value, true
+}
+
+// Max returns the largest key and its value, returns false if the tree is empty.
+func (t *RBTree) Max() (int, any, bool) {
+	if t.Root == t.NIL {
+		return 0, nil, false
+	}
+	x := t.maximumNode(t.Root)
+	return x.Key, x.Value, true
+}
+
+// Range returns an iterator over keys in [lo, hi) in ascending order.
+// The tree must not be modified during iteration.
+func (t *RBTree) Range(lo, hi int) iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for x := range t.Ascend(lo) {
+			if x.Key >= hi || !yield(x.Key, x.Value) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over all keys in ascending order.
+func (t *RBTree) All() iter.Seq2[int, any] {
+	return func(yield func(int, any) bool) {
+		for x := range t.Ascend(math.MinInt) {
+			if !yield(x.Key, x.Value) {
+				return
+			}
+		}
+	}
+}
@@ -2,26 +2,33 @@ package redblacktree
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 )
 
 // PrintTree prints the tree structure to the console
 func (t *RBTree) PrintTree() {
+	t.FprintTree(os.Stdout)
+}
+
+// FprintTree prints the tree structure to w
+func (t *RBTree) FprintTree(w io.Writer) {
 	if t.Root == t.NIL {
-		fmt.Println("Empty tree")
+		fmt.Fprintln(w, "Empty tree")
 		return
 	}
-	t.printTreeRecursive(t.Root, "", true)
+	t.printTreeRecursive(w, t.Root, "", true)
 }
 
-// printTreeRecursive is a helper function for PrintTree
-func (t *RBTree) printTreeRecursive(node *Node, prefix string, isRight bool) {
+// printTreeRecursive is a helper function for FprintTree
+func (t *RBTree) printTreeRecursive(w io.Writer, node *Node, prefix string, isRight bool) {
 	if node == t.NIL {
 		return
 	}
 
 	// Print right subtree first (will appear at the top)
-	t.printTreeRecursive(node.Right, prefix+((func() string {
+	t.printTreeRecursive(w, node.Right, prefix+((func() string {
 		if isRight {
 			return "    "
 		}
@@ -29,11 +36,11 @@ func (t *RBTree) printTreeRecursive(node *Node, prefix string, isRight bool) {
 	})()), false)
 
 	// Print current node
-	fmt.Print(prefix)
+	fmt.Fprint(w, prefix)
 	if isRight {
-		fmt.Print("└── ")
+		fmt.Fprint(w, "└── ")
 	} else {
-		fmt.Print("┌── ")
+		fmt.Fprint(w, "┌── ")
 	}
 
 	// Print with color
@@ -43,16 +50,16 @@ func (t *RBTree) printTreeRecursive(node *Node, prefix string, isRight bool) {
 	}
 
 	// Print node (with color in terminals that support ANSI)
-	fmt.Printf("%d%s", node.Key, colorName)
+	fmt.Fprintf(w, "%d%s", node.Key, colorName)
 
 	// Print value if it's a string and not too long
 	if str, ok := node.Value.(string); ok && len(str) < 10 {
-		fmt.Printf(":%s", str)
+		fmt.Fprintf(w, ":%s", str)
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// Print left subtree (will appear at the bottom)
-	t.printTreeRecursive(node.Left, prefix+((func() string {
+	t.printTreeRecursive(w, node.Left, prefix+((func() string {
 		if isRight {
 			return "    "
 		}
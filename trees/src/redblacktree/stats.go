@@ -0,0 +1,44 @@
+package redblacktree
+
+// Counters counts the rotations performed by the tree, for benchmarks.
+// Counting is disabled if RBTree.Counters is nil.
+//
+//	tree.Counters = &Counters{}
+type Counters struct {
+	LeftRotations  uint64
+	RightRotations uint64
+}
+
+// Stats describes the shape of the tree.
+type Stats struct {
+	Nodes       int
+	Height      int // number of nodes on the longest path from root to leaf, 0 for empty tree
+	BlackHeight int // number of BLACK nodes on any path from root to leaf, NIL is not counted
+	Rotations   uint64
+}
+
+// Stats returns the statistics of the tree, it walks the whole tree.
+func (t *RBTree) Stats() Stats {
+	var s Stats
+	s.Height = t.height(t.Root, &s.Nodes)
+
+	for x := t.Root; x != t.NIL; x = x.Left {
+		if x.Color == BLACK {
+			s.BlackHeight++
+		}
+	}
+
+	if t.Counters != nil {
+		s.Rotations = t.Counters.LeftRotations + t.Counters.RightRotations
+	}
+	return s
+}
+
+// height returns the height of the subtree rooted at x, and adds the number of nodes to count.
+func (t *RBTree) height(x *Node, count *int) int {
+	if x == t.NIL {
+		return 0
+	}
+	*count++
+	return 1 + max(t.height(x.Left, count), t.height(x.Right, count))
+}
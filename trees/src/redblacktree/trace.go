@@ -0,0 +1,179 @@
+package redblacktree
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tracer receives the steps of Insert and Delete, set RBTree.Tracer to enable tracing.
+//
+//	rec := NewRecorder(tree)
+//	tree.Insert(10, nil)
+//	rec.WriteTo(os.Stdout)
+//
+// Trace is called synchronously in the middle of the fixup, the tree may violate the red-black properties
+// and must not be modified.
+type Tracer interface {
+	Trace(e Event)
+}
+
+// EventKind is the kind of Event.
+type EventKind uint8
+
+const (
+	EventInsert      EventKind = iota // the new node is linked as a RED leaf, before insertFixup
+	EventDelete                       // the node is removed, before deleteFixup, Node is the replacement
+	EventCase                         // insertFixup or deleteFixup takes a case, before the case is applied
+	EventLeftRotate                   // after a left rotation, Node is the node rotated down
+	EventRightRotate                  // after a right rotation, Node is the node rotated down
+	EventRecolor                      // after the color of Node is changed
+)
+
+// Op is the operation of a fixup case.
+type Op uint8
+
+const (
+	OpInsert Op = iota
+	OpDelete
+)
+
+func (op Op) String() string {
+	if op == OpInsert {
+		return "insert"
+	}
+	return "delete"
+}
+
+// Event is a step of Insert or Delete.
+type Event struct {
+	Kind EventKind
+	Key  int   // key of Node, or the inserted / deleted key
+	Node *Node // could be NIL for EventDelete and EventCase of deleteFixup
+
+	// EventCase only, the numbers are the "Case n" comments of insertFixup (1-3) and deleteFixup (1-4).
+	Op     Op
+	Case   int
+	Mirror bool // the mirrored case: the parent (insert) or x (delete) is a right child
+
+	Color Color // EventRecolor only, the new color
+}
+
+func (e Event) String() string {
+	switch e.Kind {
+	case EventInsert:
+		return fmt.Sprintf("insert %d", e.Key)
+	case EventDelete:
+		return fmt.Sprintf("delete %d", e.Key)
+	case EventCase:
+		side := "left"
+		if e.Mirror {
+			side = "right"
+		}
+		return fmt.Sprintf("%s case %d (%s) at %s", e.Op, e.Case, side, nodeName(e.Node))
+	case EventLeftRotate:
+		return fmt.Sprintf("left rotate %d", e.Key)
+	case EventRightRotate:
+		return fmt.Sprintf("right rotate %d", e.Key)
+	case EventRecolor:
+		color := "BLACK"
+		if e.Color == RED {
+			color = "RED"
+		}
+		return fmt.Sprintf("recolor %d %s", e.Key, color)
+	}
+	return fmt.Sprintf("event(%d)", e.Kind)
+}
+
+// nodeName returns the key of x, x is the sentinel NIL if it has no children.
+func nodeName(x *Node) string {
+	if x.Left == nil && x.Right == nil {
+		return "NIL"
+	}
+	return fmt.Sprint(x.Key)
+}
+
+func (t *RBTree) trace(e Event) {
+	if t.Tracer != nil {
+		t.Tracer.Trace(e)
+	}
+}
+
+func (t *RBTree) traceCase(op Op, n int, mirror bool, x *Node) {
+	if t.Tracer != nil {
+		t.Tracer.Trace(Event{Kind: EventCase, Key: x.Key, Node: x, Op: op, Case: n, Mirror: mirror})
+	}
+}
+
+// setColor changes the color of x, EventRecolor is traced only if the color is changed.
+func (t *RBTree) setColor(x *Node, c Color) {
+	if x.Color == c {
+		return
+	}
+	x.Color = c
+	t.trace(Event{Kind: EventRecolor, Key: x.Key, Node: x, Color: c})
+}
+
+// Step is a recorded event and the tree printed right after the event.
+type Step struct {
+	Event Event
+	Tree  string
+}
+
+// CaseKey identifies a fixup case, mirrored cases are counted separately.
+type CaseKey struct {
+	Op     Op
+	Case   int
+	Mirror bool
+}
+
+// Recorder is a Tracer which records every step with the tree printed by FprintTree,
+// it can be used to write a step by step walkthrough, or to check which fixup cases are covered by a test.
+type Recorder struct {
+	Steps []Step
+	Cases map[CaseKey]int // number of times each case is taken
+
+	tree *RBTree
+}
+
+// NewRecorder creates a recorder and sets it as the tracer of t.
+func NewRecorder(t *RBTree) *Recorder {
+	r := &Recorder{Cases: make(map[CaseKey]int), tree: t}
+	t.Tracer = r
+	return r
+}
+
+// Trace records the event and the current tree.
+func (r *Recorder) Trace(e Event) {
+	if e.Kind == EventCase {
+		r.Cases[CaseKey{Op: e.Op, Case: e.Case, Mirror: e.Mirror}]++
+	}
+	var b strings.Builder
+	r.tree.FprintTree(&b)
+	r.Steps = append(r.Steps, Step{Event: e, Tree: b.String()})
+}
+
+// Reset clears the recorded steps and cases.
+func (r *Recorder) Reset() {
+	r.Steps = nil
+	clear(r.Cases)
+}
+
+// WriteTo writes all steps to w:
+//
+//	step 1: insert 10
+//	└── 10[R]
+//
+//	step 2: recolor 10 BLACK
+//	└── 10
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for i, step := range r.Steps {
+		m, err := fmt.Fprintf(w, "step %d: %s\n%s\n", i+1, step.Event, step.Tree)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,81 @@
+package redblacktree
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRecorderSteps(t *testing.T) {
+	tree := NewRBTree()
+	rec := NewRecorder(tree)
+	for _, k := range []int{10, 20, 30} {
+		tree.Insert(k, nil)
+	}
+
+	var events []string
+	for _, step := range rec.Steps {
+		events = append(events, step.Event.String())
+	}
+	want := []string{
+		"insert 10",
+		"recolor 10 BLACK",
+		"insert 20",
+		"insert 30",
+		"insert case 3 (right) at 30",
+		"recolor 20 BLACK",
+		"recolor 10 RED",
+		"left rotate 10",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %q\nwant %q", events, want)
+	}
+
+	var b strings.Builder
+	if _, err := rec.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "step 1: insert 10\n└── 10[R]\n\nstep 2: recolor 10 BLACK\n└── 10\n\n"; !strings.HasPrefix(b.String(), want) {
+		t.Errorf("WriteTo() =\n%s\nwant prefix\n%s", b.String(), want)
+	}
+	if last := rec.Steps[len(rec.Steps)-1].Tree; last != "    ┌── 30[R]\n└── 20\n    └── 10[R]\n" {
+		t.Errorf("last tree =\n%s", last)
+	}
+}
+
+// TestCaseCoverage checks that random operations take every fixup case, on both sides.
+func TestCaseCoverage(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 4)) //nolint:gosec // deterministic test.
+	tree := NewRBTree()
+	rec := NewRecorder(tree)
+
+	for range 2000 {
+		k := r.IntN(200)
+		if r.IntN(2) == 0 {
+			tree.Insert(k, nil)
+		} else {
+			tree.Delete(k)
+		}
+		rec.Steps = rec.Steps[:0] // only the cases are needed
+	}
+	if err := tree.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, op := range []Op{OpInsert, OpDelete} {
+		cases := 3
+		if op == OpDelete {
+			cases = 4
+		}
+		for n := 1; n <= cases; n++ {
+			for _, mirror := range []bool{false, true} {
+				key := CaseKey{Op: op, Case: n, Mirror: mirror}
+				if rec.Cases[key] == 0 {
+					t.Errorf("%s case %d mirror=%t is not covered", op, n, mirror)
+				}
+			}
+		}
+	}
+	t.Log(rec.Cases)
+}
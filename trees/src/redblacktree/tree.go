@@ -1,5 +1,7 @@
 package redblacktree
 
+import "iter"
+
 // Color represents the color of a node in the red-black tree
 type Color bool
 
@@ -16,12 +18,18 @@ type Node struct {
 	Left   *Node
 	Right  *Node
 	Parent *Node
+	// Summary of the subtree rooted at this node, only maintained by augmented trees, see Monoid.
+	Summary any
 }
 
 // RBTree represents a red-black tree
 type RBTree struct {
-	Root *Node
-	NIL  *Node // Sentinel node, 哨兵节点
+	Root     *Node
+	NIL      *Node     // Sentinel node, 哨兵节点
+	Counters *Counters // Optional operation counters, nil means disabled
+	Monoid   *Monoid   // Optional subtree augmentation, nil means disabled. Use NewAugmentedRBTree.
+	Tracer   Tracer    // Optional fixup tracer, nil means disabled
+	size     int
 }
 
 // NewRBTree creates a new red-black tree
@@ -33,7 +41,8 @@ func NewRBTree() *RBTree {
 	}
 }
 
-// Search finds a node with the given key
+// Search finds a node with the given key, returns t.NIL if the key is not found.
+// Use Get if the sentinel node is not needed.
 func (t *RBTree) Search(key int) *Node {
 	return t.search(t.Root, key)
 }
@@ -48,6 +57,15 @@ func (t *RBTree) search(x *Node, key int) *Node {
 	return t.search(x.Right, key)
 }
 
+// Get returns the value of the given key, and whether the key is found.
+func (t *RBTree) Get(key int) (any, bool) {
+	node := t.search(t.Root, key)
+	if node == t.NIL {
+		return nil, false
+	}
+	return node.Value, true
+}
+
 // Insert adds a new node with the given key and value
 func (t *RBTree) Insert(key int, value any) {
 	// Create new node
@@ -73,6 +91,7 @@ func (t *RBTree) Insert(key int, value any) {
 		} else {
 			// Key already exists, update value and return
 			x.Value = value
+			t.updatePath(x)
 			return
 		}
 	}
@@ -89,9 +108,12 @@ func (t *RBTree) Insert(key int, value any) {
 	} else {
 		newNodeParent.Right = newNode
 	}
+	t.updatePath(newNode)
+	t.trace(Event{Kind: EventInsert, Key: key, Node: newNode})
 
 	// Fix violations
 	t.insertFixup(newNode)
+	t.size++
 }
 
 // insertFixup fixes violations of red-black tree properties after insertion
@@ -103,10 +125,11 @@ func (t *RBTree) insertFixup(newNode *Node) {
 			uncle := newNode.Parent.Parent.Right
 			if uncle != t.NIL && uncle.Color == RED {
 				// Case 1: Uncle is red
-				newNode.Parent.Color = BLACK      // change Parent to BLACK
-				uncle.Color = BLACK               // change Uncle to BLACK
-				newNode.Parent.Parent.Color = RED // change Grandparent to RED
-				newNode = newNode.Parent.Parent   // set Grandparent as a new node
+				t.traceCase(OpInsert, 1, false, newNode)
+				t.setColor(newNode.Parent, BLACK)      // change Parent to BLACK
+				t.setColor(uncle, BLACK)               // change Uncle to BLACK
+				t.setColor(newNode.Parent.Parent, RED) // change Grandparent to RED
+				newNode = newNode.Parent.Parent        // set Grandparent as a new node
 			} else {
 				// Case 2: Uncle is NIL or BLACK
 				if newNode == newNode.Parent.Right {
@@ -119,22 +142,25 @@ func (t *RBTree) insertFixup(newNode *Node) {
 					// newNode is Grandparent's "inner grandchild"
 					// perform Left-Rotation on Parent Node, then
 					// perform Right-Rotation on Grandparent Node in Case 3.
+					t.traceCase(OpInsert, 2, false, newNode)
 					newNode = newNode.Parent
 					t.leftRotate(newNode)
 				}
 				// Case 3: Uncle is BLACK, newNode is left child - outer grandchild
-				newNode.Parent.Color = BLACK         // change Parent to BLACK
-				newNode.Parent.Parent.Color = RED    // change Grandparent to BLACK
-				t.rightRotate(newNode.Parent.Parent) // perform Right-Rotation on Grandparent Node
+				t.traceCase(OpInsert, 3, false, newNode)
+				t.setColor(newNode.Parent, BLACK)      // change Parent to BLACK
+				t.setColor(newNode.Parent.Parent, RED) // change Grandparent to BLACK
+				t.rightRotate(newNode.Parent.Parent)   // perform Right-Rotation on Grandparent Node
 			}
 		} else {
 			// Same cases but mirrored
 			uncle := newNode.Parent.Parent.Left
 			if uncle != t.NIL && uncle.Color == RED {
 				// Case 1: Uncle is red
-				newNode.Parent.Color = BLACK
-				uncle.Color = BLACK
-				newNode.Parent.Parent.Color = RED
+				t.traceCase(OpInsert, 1, true, newNode)
+				t.setColor(newNode.Parent, BLACK)
+				t.setColor(uncle, BLACK)
+				t.setColor(newNode.Parent.Parent, RED)
 				newNode = newNode.Parent.Parent
 			} else {
 				if newNode == newNode.Parent.Left {
@@ -148,29 +174,33 @@ func (t *RBTree) insertFixup(newNode *Node) {
 					// newNode is Grandparent's "inner grandchild"
 					// perform Right-Rotation on Parent Node, then
 					// perform Left-Rotation on Grandparent Node in Case 3.
+					t.traceCase(OpInsert, 2, true, newNode)
 					newNode = newNode.Parent
 					t.rightRotate(newNode)
 				}
 				// Case 3: Uncle is black, newNode is right child - outer grandchild
-				newNode.Parent.Color = BLACK
-				newNode.Parent.Parent.Color = RED
+				t.traceCase(OpInsert, 3, true, newNode)
+				t.setColor(newNode.Parent, BLACK)
+				t.setColor(newNode.Parent.Parent, RED)
 				t.leftRotate(newNode.Parent.Parent)
 			}
 		}
 	}
-	t.Root.Color = BLACK
+	t.setColor(t.Root, BLACK)
 }
 
-// Delete removes a node with the given key
-func (t *RBTree) Delete(key int) {
+// Delete removes a node with the given key, returns false if the key is not found.
+func (t *RBTree) Delete(key int) bool {
 	z := t.Search(key)
 	if z == t.NIL {
-		return
+		return false
 	}
 
 	// 以下两种删除方式都可以
 	// t.deleteWithPredecessor(z)
 	t.deleteWithSuccessor(z)
+	t.size--
+	return true
 }
 
 func (t *RBTree) deleteWithSuccessor(delNode *Node) {
@@ -238,6 +268,11 @@ func (t *RBTree) deleteWithSuccessor(delNode *Node) {
 		//      A      ...
 	}
 
+	// replacement.Parent is the lowest node whose subtree changed, it could be NIL if the root is deleted.
+	t.updatePath(replacement.Parent)
+
+	t.trace(Event{Kind: EventDelete, Key: delNode.Key, Node: replacement})
+
 	// Fix red-black properties if we removed a black node
 	if originalColor == BLACK {
 		t.deleteFixup(replacement)
@@ -261,7 +296,7 @@ func (t *RBTree) deleteWithPredecessor(delNode *Node) {
 		// 找到前驱节点 predecessor 代替, 即:左子树中最大的节点, 后继节点没有 right child.
 		predecessor := t.maximumNode(delNode.Left)
 		originalColor = predecessor.Color
-		replacement = predecessor.Right
+		replacement = predecessor.Left
 
 		if predecessor.Parent == delNode {
 			// case: predecessor 是 delNode 的 child
@@ -277,6 +312,8 @@ func (t *RBTree) deleteWithPredecessor(delNode *Node) {
 		predecessor.Right.Parent = predecessor
 		predecessor.Color = delNode.Color
 	}
+	t.updatePath(replacement.Parent)
+	t.trace(Event{Kind: EventDelete, Key: delNode.Key, Node: replacement})
 
 	if originalColor == BLACK {
 		t.deleteFixup(replacement)
@@ -291,27 +328,31 @@ func (t *RBTree) deleteFixup(x *Node) {
 			sibling := x.Parent.Right
 			if sibling.Color == RED {
 				// Case 1: x's sibling w is RED
-				sibling.Color = BLACK
-				x.Parent.Color = RED
+				t.traceCase(OpDelete, 1, false, x)
+				t.setColor(sibling, BLACK)
+				t.setColor(x.Parent, RED)
 				t.leftRotate(x.Parent) // x is left perform Left-Rotation; x is right perform Right-Rotation
 				sibling = x.Parent.Right
 			}
 			if sibling.Left.Color == BLACK && sibling.Right.Color == BLACK {
 				// Case 2: Both of w's children are BLACK
-				sibling.Color = RED
+				t.traceCase(OpDelete, 2, false, x)
+				t.setColor(sibling, RED)
 				x = x.Parent // x -> x.Parent
 			} else {
 				if sibling.Right.Color == BLACK {
 					// Case 3: w's right child is BLACK, outer child is BLACK, inner child is RED
-					sibling.Left.Color = BLACK
-					sibling.Color = RED
+					t.traceCase(OpDelete, 3, false, x)
+					t.setColor(sibling.Left, BLACK)
+					t.setColor(sibling, RED)
 					t.rightRotate(sibling)
 					sibling = x.Parent.Right
 				}
 				// Case 4: w's right child is RED, outer child is RED, inner child is BLACK
-				sibling.Color = x.Parent.Color
-				x.Parent.Color = BLACK
-				sibling.Right.Color = BLACK
+				t.traceCase(OpDelete, 4, false, x)
+				t.setColor(sibling, x.Parent.Color)
+				t.setColor(x.Parent, BLACK)
+				t.setColor(sibling.Right, BLACK)
 				t.leftRotate(x.Parent) // x is left perform Left-Rotation; x is right perform Right-Rotation
 				x = t.Root
 			}
@@ -320,37 +361,45 @@ func (t *RBTree) deleteFixup(x *Node) {
 			w := x.Parent.Left
 			if w.Color == RED {
 				// Case 1: x's sibling w is RED
-				w.Color = BLACK
-				x.Parent.Color = RED
+				t.traceCase(OpDelete, 1, true, x)
+				t.setColor(w, BLACK)
+				t.setColor(x.Parent, RED)
 				t.rightRotate(x.Parent)
 				w = x.Parent.Left
 			}
 			if w.Right.Color == BLACK && w.Left.Color == BLACK {
 				// Case 2: Both of w's children are BLACK
-				w.Color = RED
+				t.traceCase(OpDelete, 2, true, x)
+				t.setColor(w, RED)
 				x = x.Parent
 			} else {
 				if w.Left.Color == BLACK {
 					// Case 3: w's left child is BLACK
-					w.Right.Color = BLACK
-					w.Color = RED
+					t.traceCase(OpDelete, 3, true, x)
+					t.setColor(w.Right, BLACK)
+					t.setColor(w, RED)
 					t.leftRotate(w)
 					w = x.Parent.Left
 				}
 				// Case 4: w's left child is RED
-				w.Color = x.Parent.Color
-				x.Parent.Color = BLACK
-				w.Left.Color = BLACK
+				t.traceCase(OpDelete, 4, true, x)
+				t.setColor(w, x.Parent.Color)
+				t.setColor(x.Parent, BLACK)
+				t.setColor(w.Left, BLACK)
 				t.rightRotate(x.Parent)
 				x = t.Root
 			}
 		}
 	}
-	x.Color = BLACK
+	t.setColor(x, BLACK)
 }
 
 // leftRotate performs a left rotation on the given node
 func (t *RBTree) leftRotate(x *Node) {
+	if t.Counters != nil {
+		t.Counters.LeftRotations++
+	}
+
 	y := x.Right
 	x.Right = y.Left
 
@@ -370,10 +419,19 @@ func (t *RBTree) leftRotate(x *Node) {
 
 	y.Left = x
 	x.Parent = y
+
+	// x is now the child of y
+	t.update(x)
+	t.update(y)
+	t.trace(Event{Kind: EventLeftRotate, Key: x.Key, Node: x})
 }
 
 // rightRotate performs a right rotation on the given node
 func (t *RBTree) rightRotate(y *Node) {
+	if t.Counters != nil {
+		t.Counters.RightRotations++
+	}
+
 	x := y.Left
 	y.Left = x.Right
 
@@ -393,6 +451,11 @@ func (t *RBTree) rightRotate(y *Node) {
 
 	x.Right = y
 	y.Parent = x
+
+	// y is now the child of x
+	t.update(y)
+	t.update(x)
+	t.trace(Event{Kind: EventRightRotate, Key: y.Key, Node: y})
 }
 
 // replaces one subtree 'u' with another 'v'
@@ -429,6 +492,43 @@ func (t *RBTree) maximumNode(x *Node) *Node {
 	return x
 }
 
+// successor returns the node with the smallest key greater than x.Key, or t.NIL.
+func (t *RBTree) successor(x *Node) *Node {
+	if x.Right != t.NIL {
+		return t.minimumNode(x.Right)
+	}
+	// 向上查找, 直到 x 是其 parent 的 left child.
+	p := x.Parent
+	for p != t.NIL && x == p.Right {
+		x = p
+		p = p.Parent
+	}
+	return p
+}
+
+// Ascend returns an iterator over nodes with key >= from in ascending order.
+// The tree must not be modified during iteration.
+func (t *RBTree) Ascend(from int) iter.Seq[*Node] {
+	return func(yield func(*Node) bool) {
+		// find the first node >= from
+		first := t.NIL
+		for x := t.Root; x != t.NIL; {
+			if x.Key >= from {
+				first = x
+				x = x.Left
+			} else {
+				x = x.Right
+			}
+		}
+
+		for x := first; x != t.NIL; x = t.successor(x) {
+			if !yield(x) {
+				return
+			}
+		}
+	}
+}
+
 // InOrderTraversal traverses the tree in-order and executes the given function for each node
 func (t *RBTree) InOrderTraversal(fn func(*Node)) {
 	t.inOrderTraversal(t.Root, fn)
@@ -0,0 +1,183 @@
+package redblacktree
+
+import "fmt"
+
+// Node234 is a node of a 2-3-4 tree (B-tree of order 4), the fields follow bplustree.Node.
+// A node has 1 to 3 keys, an internal node has len(Keys)+1 children, all leaves have the same depth.
+//
+// NOTE: From234 不直接接受 *bplustree.Node: B+ tree 的 internal key 只是 separator, 每个 key 都会在 leaf 中
+// 再出现一次, 并且 value 只在 leaf 中, 所以 order 4 的 B+ tree 不是 2-3-4 tree. Node234 去掉了 Next 和 Parent,
+// internal node 的 Keys 和 Values 是真正的 entry.
+type Node234 struct {
+	IsLeaf   bool
+	Keys     []int
+	Values   []any
+	Children []*Node234
+}
+
+// To234 returns the equivalent 2-3-4 tree of t, nil for an empty tree.
+//
+// 每个 BLACK node 和它的 RED children 合并成一个 2-3-4 node, 所以 2-3-4 tree 的 depth 等于 black height:
+//
+//	     B                 [A B C]
+//	   /   \              /  | |  \
+//	 A[R]  C[R]    =>   a    b c   d
+//	 / \   / \
+//	a   b c   d
+//
+// It returns an error wrapping ErrCorrupt if t is not a valid red-black tree.
+func (t *RBTree) To234() (*Node234, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	if t.Root == t.NIL {
+		return nil, nil
+	}
+	return t.to234(t.Root), nil
+}
+
+// to234 converts the BLACK node x and its RED children.
+func (t *RBTree) to234(x *Node) *Node234 {
+	n := &Node234{IsLeaf: true}
+	add := func(y *Node) {
+		n.Keys = append(n.Keys, y.Key)
+		n.Values = append(n.Values, y.Value)
+	}
+	child := func(y *Node) {
+		if y != t.NIL {
+			n.IsLeaf = false
+			n.Children = append(n.Children, t.to234(y))
+		}
+	}
+
+	// children of a RED node are BLACK
+	if x.Left.Color == RED {
+		child(x.Left.Left)
+		add(x.Left)
+		child(x.Left.Right)
+	} else {
+		child(x.Left)
+	}
+	add(x)
+	if x.Right.Color == RED {
+		child(x.Right.Left)
+		add(x.Right)
+		child(x.Right.Right)
+	} else {
+		child(x.Right)
+	}
+	return n
+}
+
+// Depth returns the number of levels of the 2-3-4 tree rooted at n.
+func (n *Node234) Depth() int {
+	if n == nil {
+		return 0
+	}
+	depth := 1
+	for !n.IsLeaf && len(n.Children) > 0 && n.Children[0] != nil {
+		n = n.Children[0]
+		depth++
+	}
+	return depth
+}
+
+// From234 builds a left-leaning red-black tree from a 2-3-4 tree, root could be nil for an empty tree.
+// A 3-node becomes a BLACK node with a RED left child, a 4-node becomes a BLACK node with two RED children.
+//
+// It returns an error if root is not a valid 2-3-4 tree: wrong number of keys or children, keys out of order,
+// or leaves at different depths.
+func From234(root *Node234) (*RBTree, error) {
+	t := NewRBTree()
+	if root == nil {
+		return t, nil
+	}
+
+	depth := root.Depth()
+	var build func(n *Node234, level int, parent *Node) (*Node, error)
+	build = func(n *Node234, level int, parent *Node) (*Node, error) {
+		if n == nil {
+			return nil, fmt.Errorf("%w: nil child at level %d", ErrNot234, level)
+		}
+		if len(n.Keys) < 1 || len(n.Keys) > 3 || (n.Values != nil && len(n.Values) != len(n.Keys)) {
+			return nil, fmt.Errorf("%w: node %v has %d keys, %d values", ErrNot234, n.Keys, len(n.Keys), len(n.Values))
+		}
+		if n.IsLeaf != (len(n.Children) == 0) || (!n.IsLeaf && len(n.Children) != len(n.Keys)+1) {
+			return nil, fmt.Errorf("%w: node %v has %d children", ErrNot234, n.Keys, len(n.Children))
+		}
+		if n.IsLeaf != (level == depth) {
+			return nil, fmt.Errorf("%w: node %v is at level %d of %d", ErrNot234, n.Keys, level, depth)
+		}
+		for i := 1; i < len(n.Keys); i++ {
+			if n.Keys[i-1] >= n.Keys[i] {
+				return nil, fmt.Errorf("%w: node %v keys out of order", ErrNot234, n.Keys)
+			}
+		}
+
+		nodes := make([]*Node, len(n.Keys))
+		for i, k := range n.Keys {
+			nodes[i] = &Node{Key: k, Color: RED, Left: t.NIL, Right: t.NIL}
+			if n.Values != nil {
+				nodes[i].Value = n.Values[i]
+			}
+		}
+		t.size += len(nodes)
+
+		// top is the BLACK node, the children of n are attached to the slots from left to right.
+		type slot struct {
+			parent *Node
+			left   bool
+		}
+		var top *Node
+		var slots []slot
+		switch len(nodes) {
+		case 1:
+			top = nodes[0]
+			slots = []slot{{top, true}, {top, false}}
+		case 2:
+			top = nodes[1]
+			link(top, nodes[0], true)
+			slots = []slot{{nodes[0], true}, {nodes[0], false}, {top, false}}
+		case 3:
+			top = nodes[1]
+			link(top, nodes[0], true)
+			link(top, nodes[2], false)
+			slots = []slot{{nodes[0], true}, {nodes[0], false}, {nodes[2], true}, {nodes[2], false}}
+		}
+		top.Color = BLACK
+		top.Parent = parent
+
+		if n.IsLeaf {
+			return top, nil
+		}
+		for i, c := range n.Children {
+			x, err := build(c, level+1, slots[i].parent)
+			if err != nil {
+				return nil, err
+			}
+			link(slots[i].parent, x, slots[i].left)
+		}
+		return top, nil
+	}
+
+	root2, err := build(root, 1, t.NIL)
+	if err != nil {
+		return nil, err
+	}
+	t.Root = root2
+	if err := t.Validate(); err != nil {
+		// keys are out of order across nodes
+		return nil, fmt.Errorf("%w: %w", ErrNot234, err)
+	}
+	return t, nil
+}
+
+// link sets child as the left or right child of parent.
+func link(parent, child *Node, left bool) {
+	if left {
+		parent.Left = child
+	} else {
+		parent.Right = child
+	}
+	child.Parent = parent
+}
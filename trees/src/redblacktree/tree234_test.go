@@ -0,0 +1,126 @@
+package redblacktree
+
+import (
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// check234 checks the 2-3-4 tree rooted at n, returns its keys in order and the depths of its leaves.
+func check234(t *testing.T, n *Node234, level int, keys *[]int, depths map[int]int) {
+	t.Helper()
+	if len(n.Keys) < 1 || len(n.Keys) > 3 {
+		t.Fatalf("node %v has %d keys", n.Keys, len(n.Keys))
+	}
+	if n.IsLeaf {
+		*keys = append(*keys, n.Keys...)
+		depths[level]++
+		return
+	}
+	if len(n.Children) != len(n.Keys)+1 {
+		t.Fatalf("node %v has %d children", n.Keys, len(n.Children))
+	}
+	for i, child := range n.Children {
+		check234(t, child, level+1, keys, depths)
+		if i < len(n.Keys) {
+			*keys = append(*keys, n.Keys[i])
+		}
+	}
+}
+
+func TestTo234(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 6)) //nolint:gosec // deterministic test.
+
+	for round := range 50 {
+		tree := NewRBTree()
+		for range r.IntN(500) {
+			k := r.IntN(1000)
+			if r.IntN(4) == 0 {
+				tree.Delete(k)
+			} else {
+				tree.Insert(k, k*10)
+			}
+		}
+
+		root, err := tree.To234()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree.Len() == 0 {
+			if root != nil {
+				t.Fatalf("round %d: empty tree converts to %v", round, root.Keys)
+			}
+			continue
+		}
+
+		// black height == 2-3-4 depth, all leaves have the same depth
+		if bh := tree.Stats().BlackHeight; root.Depth() != bh {
+			t.Errorf("round %d: Depth() = %d, black height %d", round, root.Depth(), bh)
+		}
+		var keys []int
+		depths := make(map[int]int)
+		check234(t, root, 1, &keys, depths)
+		if len(depths) != 1 || depths[root.Depth()] == 0 {
+			t.Errorf("round %d: leaf depths %v, want all %d", round, depths, root.Depth())
+		}
+
+		var want []int
+		for k := range tree.All() {
+			want = append(want, k)
+		}
+		if !slices.Equal(keys, want) {
+			t.Fatalf("round %d: 2-3-4 keys %v, want %v", round, keys, want)
+		}
+
+		// round trip
+		back, err := From234(root)
+		if err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+		if back.Len() != tree.Len() || back.Stats().BlackHeight != root.Depth() {
+			t.Errorf("round %d: From234 Len() = %d, black height %d", round, back.Len(), back.Stats().BlackHeight)
+		}
+		for k, v := range back.All() {
+			if v != k*10 {
+				t.Fatalf("round %d: key %d has value %v", round, k, v)
+			}
+		}
+		again, err := back.To234()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var againKeys []int
+		check234(t, again, 1, &againKeys, make(map[int]int))
+		if !slices.Equal(againKeys, want) {
+			t.Errorf("round %d: round trip keys differ", round)
+		}
+
+		// From234 builds a left-leaning tree, the result is still a valid tree after more operations.
+		for range 50 {
+			back.Insert(r.IntN(1000), 0)
+			back.Delete(r.IntN(1000))
+		}
+		if err := back.Validate(); err != nil {
+			t.Fatalf("round %d: %v", round, err)
+		}
+	}
+}
+
+func TestFrom234Invalid(t *testing.T) {
+	leaf := func(keys ...int) *Node234 { return &Node234{IsLeaf: true, Keys: keys} }
+
+	tests := map[string]*Node234{
+		"no keys":       leaf(),
+		"4 keys":        leaf(1, 2, 3, 4),
+		"unsorted":      leaf(2, 1),
+		"few children":  {Keys: []int{5}, Children: []*Node234{leaf(1)}},
+		"uneven leaves": {Keys: []int{5}, Children: []*Node234{leaf(1), {Keys: []int{7}, Children: []*Node234{leaf(6), leaf(8)}}}},
+		"out of order":  {Keys: []int{5}, Children: []*Node234{leaf(6), leaf(7)}},
+	}
+	for name, root := range tests {
+		if _, err := From234(root); !errors.Is(err, ErrNot234) {
+			t.Errorf("%s: From234() error = %v, want ErrNot234", name, err)
+		}
+	}
+}
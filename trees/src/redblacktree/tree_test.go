@@ -1,8 +1,15 @@
 package redblacktree
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
 	"testing"
+
+	"local/src/orderedmap"
+	"local/src/orderedmap/orderedmaptest"
 )
 
 func TestRBtree(*testing.T) {
@@ -45,3 +52,105 @@ func TestRBTree2(*testing.T) {
 	tree.Delete(11)
 	tree.PrintTree()
 }
+
+func TestRBTreeValidate(t *testing.T) {
+	tree := NewRBTree()
+
+	keys := rand.Perm(500)
+	for _, k := range keys {
+		tree.Insert(k, k*10)
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, k := range keys[:250] {
+		tree.Delete(k)
+		if err := tree.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, k := range keys[:250] {
+		if v, ok := tree.Get(k); ok {
+			t.Errorf("deleted key %d found: %v", k, v)
+		}
+	}
+	for _, k := range keys[250:] {
+		if v, ok := tree.Get(k); !ok || v != k*10 {
+			t.Errorf("Get(%d) = %v, %t", k, v, ok)
+		}
+	}
+
+	// break the tree
+	tree.Root.Left.Color = RED
+	tree.Root.Left.Left.Color = RED
+	if err := tree.Validate(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Validate() = %v, want ErrCorrupt", err)
+	}
+}
+
+func TestRBTreeStats(t *testing.T) {
+	tree := NewRBTree()
+	tree.Counters = &Counters{}
+
+	const n = 1000
+	for i := range n {
+		tree.Insert(i, nil)
+	}
+
+	s := tree.Stats()
+	t.Logf("%+v", s)
+
+	if s.Nodes != n || s.Rotations == 0 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	// height <= 2*log2(n+1)
+	if float64(s.Height) > 2*math.Log2(n+1) || s.Height < s.BlackHeight {
+		t.Errorf("height %d, black height %d", s.Height, s.BlackHeight)
+	}
+}
+
+func TestRBTreeAscend(t *testing.T) {
+	tree := NewRBTree()
+	for _, k := range rand.Perm(100) {
+		tree.Insert(k*2, nil)
+	}
+
+	var got []int
+	for node := range tree.Ascend(51) {
+		got = append(got, node.Key)
+		if len(got) == 5 {
+			break
+		}
+	}
+	if want := []int{52, 54, 56, 58, 60}; !slices.Equal(got, want) {
+		t.Errorf("Ascend(51) = %v, want %v", got, want)
+	}
+}
+
+func TestOrderedMap(t *testing.T) {
+	orderedmaptest.Run(t, func() orderedmap.OrderedMap[int, any] { return NewRBTree() })
+}
+
+// deleteWithPredecessor is not used by Delete, test it directly.
+func TestRBTreeDeleteWithPredecessor(t *testing.T) {
+	tree := NewRBTree()
+	keys := rand.Perm(500)
+	for _, k := range keys {
+		tree.Insert(k, k)
+	}
+
+	for _, k := range keys[:250] {
+		tree.deleteWithPredecessor(tree.Search(k))
+		tree.size--
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("delete %d: %v", k, err)
+		}
+	}
+	for _, k := range keys[250:] {
+		if _, ok := tree.Get(k); !ok {
+			t.Errorf("Get(%d) not found", k)
+		}
+	}
+}
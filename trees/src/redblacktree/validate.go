@@ -0,0 +1,74 @@
+package redblacktree
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Validate checks the red-black tree properties, returns an error wrapping ErrCorrupt if any of them is violated.
+//
+//  1. root is BLACK, sentinel NIL is BLACK.
+//  2. a RED node has no RED child.
+//  3. every path from a node to NIL has the same number of BLACK nodes.
+//  4. left child < parent < right child, and every child points back to its parent.
+//  5. augmented tree only: every Summary is the combination of its children and itself.
+func (t *RBTree) Validate() error {
+	if t.NIL == nil || t.NIL.Color != BLACK {
+		return fmt.Errorf("%w: sentinel NIL is not BLACK", ErrCorrupt)
+	}
+	if t.Root == t.NIL {
+		return nil
+	}
+	if t.Root.Color != BLACK {
+		return fmt.Errorf("%w: root %d is RED", ErrCorrupt, t.Root.Key)
+	}
+	if t.Root.Parent != t.NIL {
+		return fmt.Errorf("%w: root %d has parent", ErrCorrupt, t.Root.Key)
+	}
+
+	_, err := t.validate(t.Root, nil, nil)
+	return err
+}
+
+// validate returns the black height of the subtree rooted at x.
+// lo, hi are nil if the subtree has no lower or upper bound.
+func (t *RBTree) validate(x *Node, lo, hi *int) (int, error) {
+	if x == t.NIL {
+		return 1, nil
+	}
+
+	if (lo != nil && x.Key <= *lo) || (hi != nil && x.Key >= *hi) {
+		return 0, fmt.Errorf("%w: node %d is out of order", ErrCorrupt, x.Key)
+	}
+	if x.Color == RED && (x.Left.Color == RED || x.Right.Color == RED) {
+		return 0, fmt.Errorf("%w: RED node %d has RED child", ErrCorrupt, x.Key)
+	}
+	for _, child := range []*Node{x.Left, x.Right} {
+		if child != t.NIL && child.Parent != x {
+			return 0, fmt.Errorf("%w: node %d has wrong parent", ErrCorrupt, child.Key)
+		}
+	}
+
+	left, err := t.validate(x.Left, lo, &x.Key)
+	if err != nil {
+		return 0, err
+	}
+	right, err := t.validate(x.Right, &x.Key, hi)
+	if err != nil {
+		return 0, err
+	}
+	if left != right {
+		return 0, fmt.Errorf("%w: node %d black height left %d, right %d", ErrCorrupt, x.Key, left, right)
+	}
+	if m := t.Monoid; m != nil {
+		want := m.Combine(m.Combine(x.Left.Summary, m.Lift(x.Key, x.Value)), x.Right.Summary)
+		if !reflect.DeepEqual(x.Summary, want) {
+			return 0, fmt.Errorf("%w: node %d summary %v, want %v", ErrCorrupt, x.Key, x.Summary, want)
+		}
+	}
+
+	if x.Color == BLACK {
+		left++
+	}
+	return left, nil
+}
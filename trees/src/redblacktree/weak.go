@@ -0,0 +1,138 @@
+package redblacktree
+
+import (
+	"runtime"
+	"sync"
+	"weak"
+)
+
+// WeakTree is a red-black tree whose values are held by weak pointers,
+// an entry is removed from the tree after its value is garbage collected.
+// It can be used to build canonicalizing caches, see LoadOrStore.
+//
+// 每个 value 都通过 runtime.AddCleanup 注册一个 cleanup 函数, value 被 GC 之后由 runtime 在
+// 另一个 goroutine 中调用 cleanup 删除对应的 key, 所以 WeakTree 使用 mutex 保护.
+//
+// NOTE: WeakTree 是 RBTree 的 wrapper, 而不是 RBTree 的 option: RBTree.Value 是 any, option 无法携带
+// weak.Pointer[V] 需要的类型参数 V, 并且 cleanup 在另一个 goroutine 中运行, 需要 RBTree 本身没有的 mutex.
+// BPlusTree 也没有 weak variant.
+type WeakTree[V any] struct {
+	mu   sync.Mutex
+	tree *RBTree // key -> *weakEntry[V]
+}
+
+type weakEntry[V any] struct {
+	ptr     weak.Pointer[V]
+	cleanup runtime.Cleanup
+}
+
+// cleanupArg must not reference the value, otherwise the value will never be collected.
+type cleanupArg[V any] struct {
+	key   int
+	entry *weakEntry[V]
+}
+
+func NewWeakTree[V any]() *WeakTree[V] {
+	return &WeakTree[V]{tree: NewRBTree()}
+}
+
+// Insert adds or replaces the value of key, the tree doesn't keep value alive.
+// A nil value is treated like a collected value: key is removed, the same as Delete.
+func (t *WeakTree[V]) Insert(key int, value *V) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.insert(key, value)
+}
+
+// Get returns the value of key, returns false if the key is not found or the value has been collected.
+func (t *WeakTree[V]) Get(key int) (*V, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.get(key)
+}
+
+// LoadOrStore returns the existing value of key if it is still alive.
+// Otherwise, it stores and returns the given value. The loaded result is true if the value was loaded.
+// Storing a nil value removes key, see Insert.
+func (t *WeakTree[V]) LoadOrStore(key int, value *V) (actual *V, loaded bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if v, ok := t.get(key); ok {
+		return v, true
+	}
+	t.insert(key, value)
+	return value, false
+}
+
+// Delete removes key from the tree.
+func (t *WeakTree[V]) Delete(key int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.delete(key)
+}
+
+// Len returns the number of entries, including entries whose value is collected
+// but the cleanup has not run yet.
+func (t *WeakTree[V]) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.tree.Len()
+}
+
+// InOrderTraversal calls fn for each key whose value is still alive, in ascending key order.
+func (t *WeakTree[V]) InOrderTraversal(fn func(key int, value *V)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tree.InOrderTraversal(func(node *Node) {
+		if v := node.Value.(*weakEntry[V]).ptr.Value(); v != nil {
+			fn(node.Key, v)
+		}
+	})
+}
+
+func (t *WeakTree[V]) get(key int) (*V, bool) {
+	e, ok := t.tree.Get(key)
+	if !ok {
+		return nil, false
+	}
+	v := e.(*weakEntry[V]).ptr.Value()
+	return v, v != nil
+}
+
+func (t *WeakTree[V]) insert(key int, value *V) {
+	// weak.Make(nil) returns a nil pointer, but runtime.AddCleanup panics on nil.
+	if value == nil {
+		t.delete(key)
+		return
+	}
+	if old, ok := t.tree.Get(key); ok {
+		old.(*weakEntry[V]).cleanup.Stop()
+	}
+
+	e := &weakEntry[V]{ptr: weak.Make(value)}
+	e.cleanup = runtime.AddCleanup(value, t.remove, cleanupArg[V]{key: key, entry: e})
+	t.tree.Insert(key, e)
+}
+
+func (t *WeakTree[V]) delete(key int) {
+	if e, ok := t.tree.Get(key); ok {
+		e.(*weakEntry[V]).cleanup.Stop()
+		t.tree.Delete(key)
+	}
+}
+
+// remove is the cleanup function, it deletes the key only if the entry has not been replaced.
+func (t *WeakTree[V]) remove(arg cleanupArg[V]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if e, ok := t.tree.Get(arg.key); ok && e.(*weakEntry[V]) == arg.entry {
+		t.tree.Delete(arg.key)
+	}
+}
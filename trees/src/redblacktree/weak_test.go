@@ -0,0 +1,105 @@
+package redblacktree
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+type blob struct {
+	name string
+	data [64]byte // 避免 tiny allocator, tiny object 的 cleanup 可能不会运行
+}
+
+// collect runs the cleanup of key directly, as the runtime does after the value is collected.
+func collect[V any](tree *WeakTree[V], key int) {
+	tree.mu.Lock()
+	e, _ := tree.tree.Get(key)
+	tree.mu.Unlock()
+	tree.remove(cleanupArg[V]{key: key, entry: e.(*weakEntry[V])})
+}
+
+func TestWeakTree(t *testing.T) {
+	tree := NewWeakTree[blob]()
+
+	keep := &blob{name: "keep"}
+	drop := &blob{name: "drop"}
+	tree.Insert(1, keep)
+	tree.Insert(2, drop)
+
+	// LoadOrStore returns the canonical value.
+	if v, loaded := tree.LoadOrStore(1, &blob{name: "other"}); !loaded || v != keep {
+		t.Errorf("LoadOrStore(1) = %v, %t", v, loaded)
+	}
+
+	collect(tree, 2)
+	if n := tree.Len(); n != 1 {
+		t.Fatalf("Len() = %d, want 1", n)
+	}
+	if _, ok := tree.Get(2); ok {
+		t.Error("key 2 should be removed by the cleanup")
+	}
+
+	// a stale cleanup must not remove the replacing entry.
+	tree.mu.Lock()
+	old, _ := tree.tree.Get(1)
+	tree.mu.Unlock()
+	replaced := &blob{name: "replaced"}
+	tree.Insert(1, replaced)
+	tree.remove(cleanupArg[blob]{key: 1, entry: old.(*weakEntry[blob])})
+	if v, ok := tree.Get(1); !ok || v != replaced {
+		t.Errorf("Get(1) = %v, %t, want replaced", v, ok)
+	}
+
+	tree.Delete(1)
+	if tree.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", tree.Len())
+	}
+	runtime.KeepAlive(keep)
+	runtime.KeepAlive(drop)
+	runtime.KeepAlive(replaced)
+}
+
+// TestWeakTreeGC depends on the garbage collector to run the cleanup.
+func TestWeakTreeGC(t *testing.T) {
+	if testing.Short() {
+		t.Skip("depends on runtime.GC and cleanup scheduling")
+	}
+
+	tree := NewWeakTree[blob]()
+
+	keep := &blob{name: "keep"}
+	tree.Insert(1, keep)
+	tree.Insert(2, &blob{name: "drop"})
+
+	// wait for the cleanup of key 2
+	deadline := time.Now().Add(5 * time.Second)
+	for tree.Len() > 1 && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if n := tree.Len(); n != 1 {
+		t.Fatalf("Len() = %d, want 1", n)
+	}
+	if v, ok := tree.Get(1); !ok || v.name != "keep" {
+		t.Errorf("Get(1) = %v, %t", v, ok)
+	}
+	runtime.KeepAlive(keep)
+}
+
+func TestWeakTreeNil(t *testing.T) {
+	tree := NewWeakTree[blob]()
+
+	v := &blob{name: "v"}
+	tree.Insert(1, v)
+	tree.Insert(1, nil) // removes key 1, must not panic
+	tree.Insert(2, nil)
+	if n := tree.Len(); n != 0 {
+		t.Errorf("Len() = %d, want 0", n)
+	}
+	if got, loaded := tree.LoadOrStore(3, nil); got != nil || loaded || tree.Len() != 0 {
+		t.Errorf("LoadOrStore(3, nil) = %v, %t, Len() = %d", got, loaded, tree.Len())
+	}
+	runtime.KeepAlive(v)
+}
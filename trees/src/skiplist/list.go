@@ -0,0 +1,170 @@
+package skiplist
+
+import (
+	"cmp"
+	"iter"
+	"math/rand/v2"
+)
+
+const (
+	maxLevel = 32
+	p        = 4 // 每个 node 有 1/p 的概率升到上一层
+)
+
+// Node represents a node in the skip list, Next[i] is the next node at level i.
+type Node[K cmp.Ordered, V any] struct {
+	Key   K
+	Value V
+	Next  []*Node[K, V]
+}
+
+// List is a skip list, keys are kept in ascending order in several linked lists.
+// Level 0 contains all nodes, each higher level skips over the nodes below it,
+// so lookup is O(log n) on average.
+//
+//	level 2: head ----------------> 5 -----------------> nil
+//	level 1: head ------> 3 ------> 5 ------> 8 -------> nil
+//	level 0: head -> 1 -> 3 -> 4 -> 5 -> 7 -> 8 -> 9 -> nil
+type List[K cmp.Ordered, V any] struct {
+	head  *Node[K, V] // sentinel node, has maxLevel next pointers
+	level int         // current highest level in use
+	size  int
+}
+
+// New creates a new skip list
+func New[K cmp.Ordered, V any]() *List[K, V] {
+	return &List[K, V]{
+		head:  &Node[K, V]{Next: make([]*Node[K, V], maxLevel)},
+		level: 1,
+	}
+}
+
+// randomLevel returns the level of a new node, level n has probability (1/p)^(n-1).
+func randomLevel() int {
+	level := 1
+	for level < maxLevel && rand.IntN(p) == 0 { //nolint:gosec // level doesn't need crypto random.
+		level++
+	}
+	return level
+}
+
+// findPrev returns the last node before key at every level, and the node at level 0 >= key.
+func (l *List[K, V]) findPrev(key K) (prev [maxLevel]*Node[K, V], next *Node[K, V]) {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.Next[i] != nil && x.Next[i].Key < key {
+			x = x.Next[i]
+		}
+		prev[i] = x
+	}
+	return prev, x.Next[0]
+}
+
+// Get returns the value of the given key, and whether the key is found.
+func (l *List[K, V]) Get(key K) (V, bool) {
+	_, x := l.findPrev(key)
+	if x != nil && x.Key == key {
+		return x.Value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Put adds or replaces the value of key.
+func (l *List[K, V]) Put(key K, value V) {
+	prev, x := l.findPrev(key)
+	if x != nil && x.Key == key {
+		x.Value = value
+		return
+	}
+
+	level := randomLevel()
+	for i := l.level; i < level; i++ {
+		prev[i] = l.head
+	}
+	l.level = max(l.level, level)
+
+	node := &Node[K, V]{Key: key, Value: value, Next: make([]*Node[K, V], level)}
+	for i := range level {
+		node.Next[i] = prev[i].Next[i]
+		prev[i].Next[i] = node
+	}
+	l.size++
+}
+
+// Delete removes key from the list, returns false if the key is not found.
+func (l *List[K, V]) Delete(key K) bool {
+	prev, x := l.findPrev(key)
+	if x == nil || x.Key != key {
+		return false
+	}
+
+	for i := range x.Next {
+		prev[i].Next[i] = x.Next[i]
+	}
+	for l.level > 1 && l.head.Next[l.level-1] == nil {
+		l.level--
+	}
+	l.size--
+	return true
+}
+
+// Len returns the number of keys in the list.
+func (l *List[K, V]) Len() int {
+	return l.size
+}
+
+// Min returns the smallest key and its value, returns false if the list is empty.
+func (l *List[K, V]) Min() (K, V, bool) {
+	x := l.head.Next[0]
+	if x == nil {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	return x.Key, x.Value, true
+}
+
+// Max returns the largest key and its value, returns false if the list is empty.
+func (l *List[K, V]) Max() (K, V, bool) {
+	x := l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.Next[i] != nil {
+			x = x.Next[i]
+		}
+	}
+	if x == l.head {
+		var (
+			k K
+			v V
+		)
+		return k, v, false
+	}
+	return x.Key, x.Value, true
+}
+
+// Range returns an iterator over keys in [lo, hi) in ascending order.
+// The list must not be modified during iteration.
+func (l *List[K, V]) Range(lo, hi K) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		_, x := l.findPrev(lo)
+		for ; x != nil && x.Key < hi; x = x.Next[0] {
+			if !yield(x.Key, x.Value) {
+				return
+			}
+		}
+	}
+}
+
+// All returns an iterator over all keys in ascending order.
+func (l *List[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for x := l.head.Next[0]; x != nil; x = x.Next[0] {
+			if !yield(x.Key, x.Value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package skiplist
+
+import (
+	"testing"
+
+	"local/src/orderedmap"
+	"local/src/orderedmap/orderedmaptest"
+)
+
+func TestOrderedMap(t *testing.T) {
+	orderedmaptest.Run(t, func() orderedmap.OrderedMap[int, any] { return New[int, any]() })
+}